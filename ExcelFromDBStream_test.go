@@ -0,0 +1,26 @@
+package xlsrpt_test
+
+import (
+	"context"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleExcelFromDBStream() {
+	// Rows are written to the file as they are read, memory usage doesn't grow with the number of rows
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Sales Extract",
+		Query:    "SELECT SaleDate, Branch, Product, Quantity, Amount FROM Sales;",
+		RepCols: []xlsrpt.RepColumns{
+			{Column: "Amount", Format: xlsrpt.FormatCurrency, SumFlag: true}},
+		AltBg:      true,
+		AutoFilter: true}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDBStream(context.Background(), repParams, database)
+}
@@ -21,6 +21,119 @@ func ExampleExcelFromDB() {
 	xlsrpt.ExcelFromDB(repParams, database)
 }
 
+func ExampleExcelFromDB_queryArgs() {
+	// Values in QueryArgs are sent to the driver, never concatenated into the query string.
+	// Placeholder syntax depends on the driver (?, $1, :1, @p1).
+	repParams := xlsrpt.RepParams{
+		RepTitle:   "VIP Customers",
+		Query:      "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer WHERE vip=? AND Balance > ?;",
+		QueryArgs:  []interface{}{1, 10000},
+		AutoFilter: true}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
+func ExampleExcelFromDB_repCols() {
+	// RepCols are matched by query column name (or by position when Column is not set)
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+		RepCols: []xlsrpt.RepColumns{
+			{Column: "CreationDate", Title: "Date Created"},
+			{Column: "CustomerNumber", Format: xlsrpt.FormatText},
+			{Column: "Balance", Title: "Customer Balance", Format: xlsrpt.FormatCurrency, SumFlag: true}},
+		AutoFilter: true}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
+func ExampleExcelFromDB_groupBy() {
+	// Rows must be ordered by the GroupBy columns, a subtotal row is added after each region and branch
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Balance by Branch",
+		Query:    "SELECT Region, Branch, CustomerNumber, Balance FROM Customer ORDER BY Region, Branch;",
+		RepCols: []xlsrpt.RepColumns{
+			{Column: "Balance", Format: xlsrpt.FormatCurrency, SumFlag: true}},
+		GroupBy:     []string{"Region", "Branch"},
+		FooterLabel: "Total"}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
+func ExampleExcelFromDB_pivot() {
+	// A row for each region and a column for each year, with the balance sum of each region and year
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Balance by Region",
+		Query:    "SELECT Region, YEAR(CreationDate) AS Year, Balance FROM Customer;",
+		Pivot: &xlsrpt.Pivot{
+			Rows:    "Region",
+			Columns: "Year",
+			Values:  "Balance",
+			Format:  xlsrpt.FormatCurrency},
+		AltBg: true}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
+func ExampleExcelFromDB_csv() {
+	// The .csv extension selects CSV output, Output: xlsrpt.OutputCSV can be used instead
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+		FilePath: "customers.csv",
+		CSV:      &xlsrpt.CSVOptions{Delimiter: ';', Decimal: ','}}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
+func ExampleExcelFromDB_ods() {
+	// The .ods extension selects OpenDocument output, for LibreOffice and other ODF applications
+	repParams := xlsrpt.RepParams{
+		RepTitle:   "Customer Report",
+		Query:      "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+		RepCols:    []xlsrpt.RepColumns{{Title: "Creation Date"}, {Title: "First Name"}, {Title: "Last Name"}, {Title: "Customer Number"}, {Title: "Balance", SumFlag: true}},
+		FilePath:   "customers.ods",
+		AutoFilter: true}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
+
 /*
 func dbConnect() (*sql.DB, error) {
 	var db *sql.DB
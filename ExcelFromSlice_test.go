@@ -0,0 +1,84 @@
+package xlsrpt_test
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+type customer struct {
+	Created xlsrpt.CellDate
+	Name    xlsrpt.CellStr
+	Number  xlsrpt.CellInt
+	Balance xlsrpt.CellCurrency
+}
+
+func ExampleExcelFromSlice() {
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		RepCols: []xlsrpt.RepColumns{
+			{Title: "Date Created", SumFlag: false},
+			{Title: "Name", SumFlag: false},
+			{Title: "Customer Number", SumFlag: false},
+			{Title: "Customer Balance", SumFlag: true}}}
+
+	// Rows are added to the report in the same order they have on the slice
+	customers := []customer{
+		{xlsrpt.CellDate(time.Now()), "John Smith", 1020, 1500.50},
+		{xlsrpt.CellDate(time.Now()), "Jane Doe", 1010, 320.00}}
+
+	xlsrpt.ExcelFromSlice(repParams, customers)
+}
+
+func ExampleSliceReport() {
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		RepCols: []xlsrpt.RepColumns{
+			{Title: "Date Created", SumFlag: false},
+			{Title: "Name", SumFlag: false},
+			{Title: "Customer Number", SumFlag: false},
+			{Title: "Customer Balance", SumFlag: true}},
+		Query: "SELECT CreationDate, Name, CustomerNumber, Balance FROM Customer ORDER BY Balance DESC;"}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// SliceReport keeps the order of the query, only a Scan function is needed
+	rptData := &xlsrpt.SliceReport[customer]{
+		Scan: func(rows *sql.Rows) (c customer, err error) {
+			var created time.Time
+			err = rows.Scan(&created, &c.Name, &c.Number, &c.Balance)
+			c.Created = xlsrpt.CellDate(created)
+			return c, err
+		}}
+
+	xlsrpt.ExcelReport(repParams, rptData, database)
+}
+
+type vipCustomer struct {
+	Created time.Time       `db:"CreationDate" xlsrpt:"title=Date Created,format=date"`
+	Name    string          `db:"FirstName" xlsrpt:"title=First Name"`
+	Number  int             `db:"CustomerNumber" xlsrpt:"title=Customer Number"`
+	Balance sql.NullFloat64 `xlsrpt:"title=Customer Balance,format=currency,sum"`
+}
+
+func ExampleSliceReport_automaticMapping() {
+	repParams := xlsrpt.RepParams{
+		RepTitle: "VIP Customers",
+		Query:    "SELECT CreationDate, FirstName, CustomerNumber, Balance FROM Customer WHERE vip=1;"}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// With no Scan function columns are loaded into the struct fields by db tag or field name
+	var rptData xlsrpt.SliceReport[vipCustomer]
+
+	xlsrpt.ExcelReport(repParams, &rptData, database)
+}
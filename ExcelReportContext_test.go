@@ -0,0 +1,38 @@
+package xlsrpt_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleExcelReportContext() {
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		RepCols: []xlsrpt.RepColumns{
+			{Title: "Date Created", SumFlag: false},
+			{Title: "First Name", SumFlag: false},
+			{Title: "Last Name", SumFlag: false},
+			{Title: "Customer Number", SumFlag: false},
+			{Title: "Customer Balance", SumFlag: true}},
+		Query: "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;"}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// Give up on the report if it takes more than a minute, no file will be written in that case
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var rptDataMap = make(repExampleMap)
+	var rptData xlsrpt.ReportData = rptDataMap
+
+	err = xlsrpt.ExcelReportContext(ctx, repParams, rptData, database)
+	if err == context.DeadlineExceeded {
+		panic("Report took too long")
+	}
+}
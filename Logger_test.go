@@ -0,0 +1,26 @@
+package xlsrpt_test
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleLogger() {
+	// Any *slog.Logger can be used as a Logger
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repParams := xlsrpt.RepParams{
+		RepTitle: "Customer Report",
+		Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+		Logger:   logger.With("report", "customers")}
+
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	xlsrpt.ExcelFromDB(repParams, database)
+}
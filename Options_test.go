@@ -0,0 +1,30 @@
+package xlsrpt_test
+
+import (
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleOptions() {
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// Each sheet can have its own configuration, safe to use from several goroutines
+	repParams := []xlsrpt.MultiSheetRep{
+		{
+			Params: xlsrpt.RepParams{
+				RepTitle: "All Customers",
+				Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+				Options:  &xlsrpt.Options{UntouchCols: []string{"CustomerNumber"}}},
+			DB: database},
+		{
+			Params: xlsrpt.RepParams{
+				RepTitle: "VIP Customers",
+				Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer WHERE vip=1;",
+				Options:  &xlsrpt.Options{UntouchStrings: true, Vervose: true}},
+			DB: database}}
+
+	xlsrpt.ExcelMultiSheetFromDB("Customer Report.xlsx", repParams)
+}
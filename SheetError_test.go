@@ -0,0 +1,36 @@
+package xlsrpt_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleSheetError() {
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	repParams := []xlsrpt.MultiSheetRep{
+		{
+			Params: xlsrpt.RepParams{
+				RepTitle: "All Customers",
+				Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;"},
+			DB: database},
+		{
+			Params: xlsrpt.RepParams{
+				RepTitle:   "VIP Customers",
+				Query:      "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer WHERE vip=1;",
+				StrictMode: true}, // Don't write the file if this sheet fails
+			DB: database}}
+
+	err = xlsrpt.ExcelMultiSheetFromDB("Customer Report.xlsx", repParams)
+
+	var sheetErr *xlsrpt.SheetError
+	if errors.As(err, &sheetErr) {
+		fmt.Printf("Sheet %s failed: %v\n", sheetErr.Sheet, sheetErr.Err)
+	}
+}
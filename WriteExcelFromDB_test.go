@@ -0,0 +1,82 @@
+package xlsrpt_test
+
+import (
+	"net/http"
+
+	"github.com/moisoto/xlsrpt"
+)
+
+func ExampleWriteExcelFromDB() {
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// The report is streamed to the client, no temporary file is needed.
+	http.HandleFunc("/customers.xlsx", func(w http.ResponseWriter, r *http.Request) {
+		repParams := xlsrpt.RepParams{
+			RepTitle:   "Customer Report",
+			Query:      "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+			AutoFilter: true}
+
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", `attachment; filename="customers.xlsx"`)
+		err := xlsrpt.WriteExcelFromDB(r.Context(), w, repParams, database)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func ExampleWriteExcelFromDB_html() {
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// A preview of the report, as an HTML table that can be embedded on a page or an email
+	http.HandleFunc("/customers.html", func(w http.ResponseWriter, r *http.Request) {
+		repParams := xlsrpt.RepParams{
+			RepTitle: "Customer Report",
+			Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+			RepCols: []xlsrpt.RepColumns{
+				{Title: "Creation Date"}, {Title: "First Name"}, {Title: "Last Name"},
+				{Title: "Customer Number"}, {Title: "Balance", SumFlag: true}},
+			AltBg:  true,
+			Output: xlsrpt.OutputHTML}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := xlsrpt.WriteExcelFromDB(r.Context(), w, repParams, database)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func ExampleWriteExcelFromDB_json() {
+	// Open Connection to Database
+	database, err := dbConnect()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// The report data for API clients, with the title, query and totals along with the rows
+	http.HandleFunc("/api/customers", func(w http.ResponseWriter, r *http.Request) {
+		repParams := xlsrpt.RepParams{
+			RepTitle: "Customer Report",
+			Query:    "SELECT CreationDate, FirstName, LastName, CustomerNumber, Balance FROM Customer;",
+			RepCols: []xlsrpt.RepColumns{
+				{Title: "creationDate"}, {Title: "firstName"}, {Title: "lastName"},
+				{Title: "customerNumber"}, {Title: "balance", SumFlag: true}},
+			Output: xlsrpt.OutputJSON,
+			JSON:   &xlsrpt.JSONOptions{Metadata: true}}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := xlsrpt.WriteExcelFromDB(r.Context(), w, repParams, database)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Aggregates for RepColumns.Aggregate, shown on the footer row.
+// All of them except AggCountDistinct ignore rows hidden by the autofilter (SUBTOTAL).
+const (
+	AggSum           = "sum"
+	AggAverage       = "average"
+	AggCount         = "count"
+	AggCountDistinct = "countdistinct"
+	AggMin           = "min"
+	AggMax           = "max"
+)
+
+// subtotalFunctions are the SUBTOTAL function numbers (ignoring hidden rows) of each aggregate.
+var subtotalFunctions = map[string]int{
+	AggAverage: 101,
+	AggCount:   103,
+	AggMax:     104,
+	AggMin:     105,
+	AggSum:     109,
+}
+
+// aggregate returns the aggregate of col, empty if the column has no total.
+func (col RepColumns) aggregate() string {
+	switch {
+	case col.Aggregate != "":
+		return strings.ToLower(col.Aggregate)
+	case col.SumFlag:
+		return AggSum
+	}
+	return ""
+}
+
+// hasTotal returns true if the footer row has a value for col.
+func (col RepColumns) hasTotal() bool {
+	return col.Formula != "" || col.aggregate() != ""
+}
+
+// totalFormula returns the footer formula of col, rng is the range of the column data (i.e. "B5:B20").
+func (col RepColumns) totalFormula(rng string) (string, error) {
+	if col.Formula != "" {
+		return strings.ReplaceAll(col.Formula, "{range}", rng), nil
+	}
+
+	agg := col.aggregate()
+	if agg == AggCountDistinct {
+		return fmt.Sprintf(`=SUMPRODUCT((%[1]s<>"")/COUNTIF(%[1]s,%[1]s&""))`, rng), nil
+	}
+	fn, ok := subtotalFunctions[agg]
+	if !ok {
+		return "", fmt.Errorf("column %q: unknown aggregate %q", col.Title, col.Aggregate)
+	}
+	return fmt.Sprintf("=SUBTOTAL(%d,%s)", fn, rng), nil
+}
+
+// totalFormat returns the number format of the footer cell of col.
+func (col RepColumns) totalFormat() string {
+	if col.TotalFormat != "" {
+		return formatCode(col.TotalFormat)
+	}
+	switch col.aggregate() {
+	case AggCount, AggCountDistinct:
+		return "#,##0"
+	}
+	if format := formatCode(col.Format); format != "" {
+		return format
+	}
+	return "$#,##0.00"
+}
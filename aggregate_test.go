@@ -0,0 +1,64 @@
+package xlsrpt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestTotalFormula(t *testing.T) {
+	tests := []struct {
+		col    RepColumns
+		want   string
+		format string
+	}{
+		{RepColumns{SumFlag: true}, "=SUBTOTAL(109,B2:B9)", "$#,##0.00"},
+		{RepColumns{Aggregate: AggAverage, Format: FormatDecimal}, "=SUBTOTAL(101,B2:B9)", "#,##0"},
+		{RepColumns{Aggregate: AggCount}, "=SUBTOTAL(103,B2:B9)", "#,##0"},
+		{RepColumns{Aggregate: "MIN", TotalFormat: "0.0"}, "=SUBTOTAL(105,B2:B9)", "0.0"},
+		{RepColumns{Aggregate: AggMax, TotalFormat: FormatInt}, "=SUBTOTAL(104,B2:B9)", "0"},
+		{RepColumns{Aggregate: AggCountDistinct}, `=SUMPRODUCT((B2:B9<>"")/COUNTIF(B2:B9,B2:B9&""))`, "#,##0"},
+		{RepColumns{Formula: "=SUBTOTAL(109,{range})*0.16", Format: FormatCurrency}, "=SUBTOTAL(109,B2:B9)*0.16", "$#,##0.00"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.col.totalFormula("B2:B9")
+		if err != nil {
+			t.Errorf("%+v: %v", tt.col, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%+v: formula = %q, want %q", tt.col, got, tt.want)
+		}
+		if format := tt.col.totalFormat(); format != tt.format {
+			t.Errorf("%+v: format = %q, want %q", tt.col, format, tt.format)
+		}
+	}
+
+	if _, err := (RepColumns{Aggregate: "median"}).totalFormula("B2:B9"); err == nil {
+		t.Error("unknown aggregate returned no error")
+	}
+}
+
+type footerTestRow struct {
+	Region  CellStr
+	Balance CellDecimal `xlsrpt:"agg=average"`
+}
+
+func TestFooterLabel(t *testing.T) {
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "Footer", NoTitleRow: true, FooterLabel: "Total", Logger: discardLogger{}}
+	rows := []footerTestRow{{"North", 10}, {"South", 20}}
+	if err := genSheet(context.Background(), file, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := file.Sheets[0]
+	if got := sheet.Cell(3, 0).Value; got != "Total" {
+		t.Errorf("footer label = %q, want %q", got, "Total")
+	}
+	if got := sheet.Cell(3, 1).Formula(); got != "=SUBTOTAL(101,B2:B3)" {
+		t.Errorf("footer formula = %q", got)
+	}
+}
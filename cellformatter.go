@@ -1,9 +1,9 @@
 package xlsrpt
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +32,18 @@ type CellCurrency float32
 // CellDate - Date Cell Type.
 type CellDate time.Time
 
+// Column formats, used on RepColumns.Format and on the format option of struct tags.
+// Each format renders cells as the Cell type of the same name.
+const (
+	FormatText     = "text"
+	FormatInt      = "int"
+	FormatNumeric  = "numeric"
+	FormatDecimal  = "decimal"
+	FormatPercent  = "percent"
+	FormatCurrency = "currency"
+	FormatDate     = "date"
+)
+
 /*
 // *** No Need for this, since we are not using cellAdder objects (yet) ***
 // *** Will leave commented just in case ***
@@ -44,6 +56,7 @@ type cellAdder interface {
 */
 
 // Library behavior configuration variables.
+// These are used as defaults for reports with no Options set, see DefaultOptions.
 var (
 	// LogBench can be used to log benchmark information of report creation (unimplemented).
 	LogBench bool
@@ -55,68 +68,246 @@ var (
 	UntouchCols []string
 )
 
-// AddRow adds a row to excel report.
-func addRow(fields interface{}, row *xlsx.Row, flag bool) {
-	var timeKind = reflect.TypeOf(time.Time{}).Kind()
-
+// AddRow adds a row to excel report, with a cell for each column.
+// NULL values (nil pointers and invalid sql.Null* values) are added as opts.NullText.
+func addRow(fields interface{}, row *xlsx.Row, flag bool, cols []column, opts *Options, log Logger) {
 	if reflect.ValueOf(fields).Kind() != reflect.Struct {
-		fmt.Println("Logic Error. It's not a Struct!")
+		log.Warn("Logic Error. It's not a Struct!", "type", reflect.TypeOf(fields))
 		return
 	}
 
 	f := reflect.ValueOf(fields)
 
-	for i := 0; i < f.NumField(); i++ {
-		switch f.Field(i).Kind() {
-		case reflect.Int:
-			v := CellInt(f.Field(i).Int())
-			altBgColor(v.addCell(row), flag)
-		case reflect.String:
-			v := CellStr(f.Field(i).String())
-			altBgColor(v.addCell(row), flag)
-		case reflect.Float64:
-			v := CellDecimal(f.Field(i).Float())
-			altBgColor(v.addCell(row), flag)
-		case reflect.Float32:
-			v := CellCurrency(f.Field(i).Float())
-			altBgColor(v.addCell(row), flag)
-		case timeKind:
-			v := CellDate(f.Field(i).Interface().(CellDate))
-			altBgColor(v.addCell(row), flag)
+	for _, col := range cols {
+		v, ok := unwrapValue(f.Field(col.field))
+		switch {
+		case !ok:
+			null := CellStr(opts.NullText)
+			altBgColor(null.addCell(row), flag)
+		case col.Format != "":
+			altBgColor(addFormatCell(v, col.Format, row), flag)
 		default:
-			v := CellStr("unimplemented")
-			altBgColor(v.addCell(row), flag)
+			altBgColor(addValueCell(v, row), flag)
 		}
 	}
 
 }
 
-func addMapRow(ordColumns []string, mapRow map[string]interface{}, row *xlsx.Row, flag bool) {
+// addValueCell adds a cell with v, the cell type depends on the kind of v.
+func addValueCell(val reflect.Value, row *xlsx.Row) (cell *xlsx.Cell) {
 	var timeKind = reflect.TypeOf(time.Time{}).Kind()
 
-	// Preload length of UntouchCols so we don't do it for each column
-	l := len(UntouchCols)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := CellInt(val.Int())
+		return v.addCell(row)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v := CellInt(val.Uint())
+		return v.addCell(row)
+	case reflect.String:
+		v := CellStr(val.String())
+		return v.addCell(row)
+	case reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			v := CellStr(val.Bytes())
+			return v.addCell(row)
+		}
+	case reflect.Bool:
+		cell = row.AddCell()
+		s := cell.GetStyle()
+		s.Alignment.Horizontal = "left"
+		cell.SetBool(val.Bool())
+		return cell
+	case reflect.Float64:
+		v := CellDecimal(val.Float())
+		return v.addCell(row)
+	case reflect.Float32:
+		v := CellCurrency(val.Float())
+		return v.addCell(row)
+	case timeKind:
+		if t, ok := valueTime(val); ok {
+			v := CellDate(t)
+			return v.addCell(row)
+		}
+	}
+	v := CellStr("unimplemented")
+	return v.addCell(row)
+}
+
+// addFormatCell adds a cell with val using format (a Format constant or an excel number format).
+// If val can't be converted to the format, the cell is added as in addValueCell.
+func addFormatCell(val reflect.Value, format string, row *xlsx.Row) (cell *xlsx.Cell) {
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8 {
+		val = reflect.ValueOf(string(val.Bytes()))
+	}
 
-	for _, v := range ordColumns {
+	switch format {
+	case FormatText:
+		v := CellStr(fmt.Sprint(val.Interface()))
+		return v.addCell(row)
+	case FormatDate:
+		if t, ok := valueTime(val); ok {
+			v := CellDate(t)
+			return v.addCell(row)
+		}
+		if t, ok := parseTime(val); ok {
+			v := CellDate(t)
+			return v.addCell(row)
+		}
+		return addValueCell(val, row)
+	}
+
+	if t, ok := valueTime(val); ok {
+		cell = row.AddCell()
+		s := cell.GetStyle()
+		s.Alignment.Horizontal = "left"
+		s.ApplyAlignment = true
+		cell.SetDateTimeWithFormat(excelTime(t), format)
+		return cell
+	}
+
+	f, ok := valueFloat(val)
+	if !ok {
+		return addValueCell(val, row)
+	}
+
+	switch format {
+	case FormatInt:
+		v := CellInt(int(f))
+		return v.addCell(row)
+	case FormatNumeric:
+		v := CellNumeric(f)
+		return v.addCell(row)
+	case FormatDecimal:
+		v := CellDecimal(f)
+		return v.addCell(row)
+	case FormatPercent:
+		v := CellPercent(f)
+		return v.addCell(row)
+	case FormatCurrency:
+		// Not a CellCurrency, float32 would lose digits of database money and decimal values
+		return addFloatCell(f, "$#,##0.00", row)
+	default:
+		return addFloatCell(f, format, row)
+	}
+}
+
+// unwrapValue returns the value to be added to a cell for val.
+// Pointers are dereferenced and driver.Valuer types (i.e. sql.NullString) are replaced by their value.
+// ok is false when the value is NULL.
+func unwrapValue(val reflect.Value) (v reflect.Value, ok bool) {
+	var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	for val.IsValid() {
+		switch {
+		case val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface:
+			if val.IsNil() {
+				return val, false
+			}
+			val = val.Elem()
+		case val.CanInterface() && val.Type().Implements(valuerType):
+			dv, err := val.Interface().(driver.Valuer).Value()
+			if err != nil || dv == nil {
+				return val, false
+			}
+			return reflect.ValueOf(dv), true
+		default:
+			return val, true
+		}
+	}
+	return val, false
+}
+
+// valueFloat returns val as a float64, strings are parsed.
+func valueFloat(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// valueTime returns val as a time.Time if its type is time.Time based (i.e. CellDate).
+func valueTime(val reflect.Value) (time.Time, bool) {
+	var timeType = reflect.TypeOf(time.Time{})
+
+	if val.IsValid() && val.CanInterface() && val.Type().ConvertibleTo(timeType) {
+		return val.Convert(timeType).Interface().(time.Time), true
+	}
+	return time.Time{}, false
+}
+
+// timeLayouts are the layouts used to parse dates returned as text by the driver (i.e. MySQL).
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// parseTime returns the time on the string val, if it has one of the timeLayouts.
+func parseTime(val reflect.Value) (time.Time, bool) {
+	if val.Kind() != reflect.String {
+		return time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(val.String())); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatCode returns the excel number format for format, empty if not a number format.
+func formatCode(format string) string {
+	switch format {
+	case FormatText, FormatDate:
+		return ""
+	case FormatInt:
+		return "0"
+	case FormatNumeric:
+		return "general"
+	case FormatDecimal:
+		return "#,##0"
+	case FormatPercent:
+		return "0.00%"
+	case FormatCurrency:
+		return "$#,##0.00"
+	}
+	return format
+}
+
+// addMapRow adds a row with the values of mapRow in the order of ordColumns.
+// formats holds the format of each column, when empty the cell type is inferred from the value.
+func addMapRow(ordColumns []string, formats []string, mapRow map[string]interface{}, row *xlsx.Row, flag bool, opts *Options, log Logger) {
+	var timeKind = reflect.TypeOf(time.Time{}).Kind()
+
+	for c, v := range ordColumns {
 		val := reflect.ValueOf(mapRow[v])
+		if b, ok := mapRow[v].([]byte); ok {
+			// Drivers like MySQL return most values as bytes
+			val = reflect.ValueOf(string(b))
+		}
+		if formats[c] != "" && val.IsValid() {
+			altBgColor(addFormatCell(val, formats[c], row), flag)
+			continue
+		}
 		switch val.Kind() {
 		case reflect.Int64:
 			v := CellInt(val.Int())
 			altBgColor(v.addCell(row), flag)
 		case reflect.String:
 			goStr := true
-			untouchCol := false
 			str := val.String()
-			if l > 0 {
-				i := sort.SearchStrings(UntouchCols, v)
-				//fmt.Println("Checking untouchCols for Column", v, )
-				found := (i < l && UntouchCols[i] == v)
-				if found {
-					untouchCol = true
-				}
-			}
-			if !UntouchStrings && !untouchCol {
-				// fmt.Println("Formatting Column", v, "original value:", sort.SearchStrings(UntouchCols, v))
+			if !opts.UntouchStrings && !opts.untouchCol(v) {
 				goStr = false
 				nType, f := isNum(str)
 				switch nType {
@@ -146,12 +337,19 @@ func addMapRow(ordColumns []string, mapRow map[string]interface{}, row *xlsx.Row
 			s := cell.GetStyle()
 			s.Alignment.Horizontal = "left"
 			s.ApplyAlignment = true
-			cell.SetDateTime(v)
+			cell.SetDateTimeWithFormat(excelTime(v), xlsx.DefaultDateTimeFormat)
+			altBgColor(cell, flag)
+		case reflect.Bool:
+			cell := row.AddCell()
+			s := cell.GetStyle()
+			s.Alignment.Horizontal = "left"
+			cell.SetBool(val.Bool())
 			altBgColor(cell, flag)
+		case reflect.Invalid: // NULL
+			null := CellStr(opts.NullText)
+			altBgColor(null.addCell(row), flag)
 		default:
-			if Vervose {
-				fmt.Printf("Invalid Column Type \"%v\" for Column \"%s\" of Value \"%v\"\n", val.Kind(), v, val)
-			}
+			log.Info("Invalid Column Type", "column", v, "kind", val.Kind(), "value", val)
 			var empty CellStr
 			altBgColor(empty.addCell(row), flag)
 		}
@@ -188,7 +386,9 @@ func (data CellPercent) addCell(row *xlsx.Row) (cell *xlsx.Cell) {
 }
 
 func (data CellCurrency) addCell(row *xlsx.Row) (cell *xlsx.Cell) {
-	return addFloatCell(float64(data), "$#,##0.00", row)
+	// Saved with the digits of the float32 value (i.e. 19.99 and not 19.989999771118164)
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(data), 'g', -1, 32), 64)
+	return addFloatCell(f, "$#,##0.00", row)
 }
 
 func (data CellDate) addCell(row *xlsx.Row) (cell *xlsx.Cell) {
@@ -197,10 +397,17 @@ func (data CellDate) addCell(row *xlsx.Row) (cell *xlsx.Cell) {
 	s.Alignment.Horizontal = "left"
 	s.ApplyAlignment = true
 
-	cell.SetDateTime(time.Time(data))
+	cell.SetDateTimeWithFormat(excelTime(time.Time(data)), xlsx.DefaultDateTimeFormat)
 	return cell
 }
 
+// excelTime returns t as an excel date keeping its wall clock, since excel dates have no time zone.
+func excelTime(t time.Time) float64 {
+	y, m, d := t.Date()
+	h, min, sec := t.Clock()
+	return xlsx.TimeToExcelTime(time.Date(y, m, d, h, min, sec, t.Nanosecond(), time.UTC), false)
+}
+
 func addFloatCell(data float64, format string, row *xlsx.Row) (cell *xlsx.Cell) {
 	cell = row.AddCell()
 	s := cell.GetStyle()
@@ -219,7 +426,7 @@ func altBgColor(cell *xlsx.Cell, flag bool) {
 	if flag == true {
 		s = cell.GetStyle()
 		s.Fill.PatternType = "solid"
-		s.Fill.FgColor = "00B4C6E7"
+		s.Fill.FgColor = altBgFill
 		s.ApplyFill = true
 	}
 }
@@ -0,0 +1,71 @@
+package xlsrpt
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+type nullTestRow struct {
+	Name    sql.NullString
+	Number  sql.NullInt64
+	Balance sql.NullFloat64
+	Created sql.NullTime
+	Notes   *string
+	Date    CellDate
+	Small   int16
+}
+
+func TestAddRowNullTypes(t *testing.T) {
+	created := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	notes := "vip"
+	cols, err := structColumns(reflect.TypeOf(nullTestRow{}), nil, discardLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{NullText: "-"}
+
+	sheet, err := xlsx.NewFile().AddSheet("nulls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := nullTestRow{
+		sql.NullString{String: "John", Valid: true},
+		sql.NullInt64{Int64: 10, Valid: true},
+		sql.NullFloat64{Float64: 1.5, Valid: true},
+		sql.NullTime{Time: created, Valid: true},
+		&notes,
+		CellDate(created),
+		7}
+	addRow(valid, sheet.AddRow(), false, cols, opts, discardLogger{})
+	addRow(nullTestRow{}, sheet.AddRow(), false, cols, opts, discardLogger{})
+
+	cells := sheet.Rows[0].Cells
+	if cells[0].Value != "John" || cells[4].Value != "vip" {
+		t.Errorf("string cells = %q, %q", cells[0].Value, cells[4].Value)
+	}
+	if n, _ := cells[1].Int(); n != 10 {
+		t.Errorf("NullInt64 cell = %q", cells[1].Value)
+	}
+	if f, _ := cells[2].Float(); f != 1.5 {
+		t.Errorf("NullFloat64 cell = %q", cells[2].Value)
+	}
+	for _, c := range []int{3, 5} {
+		if !cells[c].IsTime() {
+			t.Errorf("cell %d is not a date", c)
+		}
+	}
+	if n, _ := cells[6].Int(); n != 7 {
+		t.Errorf("int16 cell = %q", cells[6].Value)
+	}
+
+	for i, cell := range sheet.Rows[1].Cells[:5] {
+		if cell.Value != "-" {
+			t.Errorf("NULL cell %d = %q, want %q", i, cell.Value, "-")
+		}
+	}
+}
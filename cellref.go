@@ -0,0 +1,51 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxColumns is the maximum number of columns of an excel sheet (A to XFD).
+const MaxColumns = 16384
+
+// ColumnName returns the excel column name for the zero based column index col (0 is "A", 26 is "AA").
+// Empty string is returned for negative indexes.
+func ColumnName(col int) string {
+	var name []byte
+	for col >= 0 {
+		name = append([]byte{byte('A' + col%26)}, name...)
+		col = col/26 - 1
+	}
+	return string(name)
+}
+
+// ColumnIndex returns the zero based column index of the excel column name (i.e. "AA" is 26).
+func ColumnIndex(name string) (int, error) {
+	if name == "" || len(name) > 3 {
+		return 0, fmt.Errorf("invalid column name %q", name)
+	}
+
+	col := 0
+	for _, r := range strings.ToUpper(name) {
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("invalid column name %q", name)
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	if col > MaxColumns {
+		return 0, fmt.Errorf("column %q is beyond the last excel column (XFD)", name)
+	}
+	return col - 1, nil
+}
+
+// CellRef returns the reference of a cell (i.e. "B5"),
+// col is the zero based column index and row the row number as shown by excel (starting at 1).
+func CellRef(col, row int) string {
+	return ColumnName(col) + strconv.Itoa(row)
+}
+
+// RangeRef returns the reference of the range between two cells (i.e. "B5:B20"), see CellRef.
+func RangeRef(col1, row1, col2, row2 int) string {
+	return CellRef(col1, row1) + ":" + CellRef(col2, row2)
+}
@@ -0,0 +1,82 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		col  int
+		name string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {51, "AZ"}, {52, "BA"}, {59, "BH"},
+		{701, "ZZ"}, {702, "AAA"}, {MaxColumns - 1, "XFD"},
+	}
+
+	for _, tt := range tests {
+		if got := ColumnName(tt.col); got != tt.name {
+			t.Errorf("ColumnName(%d) = %q, want %q", tt.col, got, tt.name)
+		}
+		if got, err := ColumnIndex(tt.name); err != nil || got != tt.col {
+			t.Errorf("ColumnIndex(%q) = %d, %v, want %d", tt.name, got, err, tt.col)
+		}
+	}
+
+	if got := ColumnName(-1); got != "" {
+		t.Errorf("ColumnName(-1) = %q, want empty", got)
+	}
+	if got, err := ColumnIndex("az"); err != nil || got != 51 {
+		t.Errorf("ColumnIndex(\"az\") = %d, %v", got, err)
+	}
+	for _, name := range []string{"", "A1", "XFE", "AAAA", "-"} {
+		if _, err := ColumnIndex(name); err == nil {
+			t.Errorf("ColumnIndex(%q) returned no error", name)
+		}
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	if got := CellRef(1, 5); got != "B5" {
+		t.Errorf("CellRef(1, 5) = %q", got)
+	}
+	if got := RangeRef(27, 2, 27, 61); got != "AB2:AB61" {
+		t.Errorf("RangeRef(27, 2, 27, 61) = %q", got)
+	}
+}
+
+func TestWideSheetReferences(t *testing.T) {
+	const ncols = 60
+	res := fakeResult{types: make([]string, ncols), rows: [][]driver.Value{make([]driver.Value, ncols), make([]driver.Value, ncols)}}
+	repCols := make([]RepColumns, ncols)
+	for c := 0; c < ncols; c++ {
+		res.cols = append(res.cols, fmt.Sprintf("Col%d", c))
+		res.types[c] = "INT"
+		res.rows[0][c], res.rows[1][c] = "1", "2"
+		repCols[c] = RepColumns{Title: res.cols[c], SumFlag: true}
+	}
+	db := fakeDB(map[string]fakeResult{"wide": res})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "Wide", Query: "wide", RepCols: repCols, AutoFilter: true, Logger: discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := file.Sheets[0]
+	if af := sheet.AutoFilter; af == nil || af.TopLeftCell != "A4" || af.BottomRightCell != "BH6" {
+		t.Errorf("autofilter = %+v, want A4:BH6", af)
+	}
+	footer := sheet.Rows[len(sheet.Rows)-1]
+	if got := footer.Cells[51].Formula(); got != "=SUBTOTAL(109,AZ5:AZ6)" {
+		t.Errorf("column 52 formula = %q", got)
+	}
+	if got := footer.Cells[59].Formula(); got != "=SUBTOTAL(109,BH5:BH6)" {
+		t.Errorf("column 60 formula = %q", got)
+	}
+}
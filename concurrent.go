@@ -0,0 +1,124 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tealeg/xlsx"
+)
+
+// sheetGen adds the sheet (or sheets) of rep to file.
+type sheetGen func(ctx context.Context, file *xlsx.File, rep MultiSheetRep) error
+
+// concurrentSheets generates the sheets of several reports concurrently, each one on its own workbook.
+type concurrentSheets struct {
+	cancel context.CancelFunc
+	files  []*xlsx.File
+	errs   []error
+	done   []chan struct{} // Closed when the report is finished (or skipped)
+}
+
+// genConcurrently starts generating the sheets of reports using gen, up to n at the same time
+// (see Options.Concurrency). Reports are started in order, the stop method must be called once the
+// results are no longer needed.
+func genConcurrently(ctx context.Context, reports []MultiSheetRep, gen sheetGen, n int) *concurrentSheets {
+	ctx, cancel := context.WithCancel(ctx)
+	cs := &concurrentSheets{
+		cancel: cancel,
+		files:  make([]*xlsx.File, len(reports)),
+		errs:   make([]error, len(reports)),
+		done:   make([]chan struct{}, len(reports)),
+	}
+	for i := range cs.done {
+		cs.done[i] = make(chan struct{})
+	}
+
+	// A semaphore for the reports and another one for each DB
+	sem := make(chan struct{}, n)
+	dbSems := make(map[*sql.DB]chan struct{})
+	for _, k := range reports {
+		if _, ok := dbSems[k.DB]; ok || k.DB == nil {
+			continue
+		}
+		size := n
+		if conns := k.DB.Stats().MaxOpenConnections; conns > 0 && conns < size {
+			size = conns
+		}
+		dbSems[k.DB] = make(chan struct{}, size)
+	}
+
+	go func() {
+		for i, k := range reports {
+			i, k := i, k
+			dbSem := dbSems[k.DB]
+			if !acquire(ctx, sem) {
+				cs.skip(i, ctx.Err())
+				return
+			}
+			if dbSem != nil && !acquire(ctx, dbSem) {
+				<-sem
+				cs.skip(i, ctx.Err())
+				return
+			}
+
+			go func() {
+				defer close(cs.done[i])
+				defer func() {
+					<-sem
+					if dbSem != nil {
+						<-dbSem
+					}
+				}()
+				cs.files[i] = xlsx.NewFile()
+				cs.errs[i] = gen(ctx, cs.files[i], k)
+			}()
+		}
+	}()
+
+	return cs
+}
+
+// acquire takes a slot of sem, false is returned if ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// skip finishes the reports from i onwards with err.
+func (cs *concurrentSheets) skip(i int, err error) {
+	for ; i < len(cs.done); i++ {
+		cs.errs[i] = err
+		close(cs.done[i])
+	}
+}
+
+// result waits for report i and returns the workbook with its sheets.
+func (cs *concurrentSheets) result(i int) (*xlsx.File, error) {
+	<-cs.done[i]
+	return cs.files[i], cs.errs[i]
+}
+
+// stop cancels the reports still running and waits for them to finish.
+func (cs *concurrentSheets) stop() {
+	cs.cancel()
+	for _, done := range cs.done {
+		<-done
+	}
+}
+
+// appendSheets adds the sheets of src to file.
+func appendSheets(file, src *xlsx.File) error {
+	if src == nil {
+		return nil
+	}
+	for _, sheet := range src.Sheets {
+		if _, err := file.AppendSheet(*sheet, sheet.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
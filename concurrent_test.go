@@ -0,0 +1,122 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// setConcurrency sets the Concurrency option of the reports, the Options of the first one are used.
+func setConcurrency(reports []MultiSheetRep, n int) {
+	reports[0].Params.Options = &Options{Concurrency: n}
+}
+
+func concurrentTestDB() map[string]fakeResult {
+	res := func(delay time.Duration, name string) fakeResult {
+		return fakeResult{cols: []string{"Name"}, rows: [][]driver.Value{{name}}, delay: delay}
+	}
+	return map[string]fakeResult{
+		"slow":   res(60*time.Millisecond, "slow"),
+		"medium": res(30*time.Millisecond, "medium"),
+		"fast":   res(0, "fast"),
+	}
+}
+
+func TestConcurrentMultiSheetFromDB(t *testing.T) {
+	db := fakeDB(concurrentTestDB())
+	defer db.Close()
+
+	reports := []MultiSheetRep{
+		{Params: RepParams{RepSheet: "Slow", Query: "slow", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Medium", Query: "medium", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Failed", Query: "missing", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Fast", Query: "fast", Logger: discardLogger{}}, DB: db},
+	}
+	setConcurrency(reports, 4)
+
+	fakeResetPeak()
+	file, err := buildMultiSheetFromDB(context.Background(), reports)
+	if file == nil {
+		t.Fatalf("no file returned: %v", err)
+	}
+	var sheetErr *SheetError
+	if !errors.As(err, &sheetErr) || sheetErr.Sheet != "Failed" {
+		t.Errorf("err = %v, want error of sheet Failed", err)
+	}
+	if peak := fakePeakQueries(); peak < 2 {
+		t.Errorf("queries were not run concurrently (peak %d)", peak)
+	}
+
+	// Sheets are added in the order of the reports
+	for i, name := range []string{"Slow", "Medium", "Failed", "Fast"} {
+		if i >= len(file.Sheets) || file.Sheets[i].Name != name {
+			t.Fatalf("sheet %d is not %q (%d sheets)", i+1, name, len(file.Sheets))
+		}
+	}
+	if got := file.Sheets[0].Cell(4, 0).Value; got != "slow" {
+		t.Errorf("Slow sheet value = %q", got)
+	}
+}
+
+func TestConcurrentMaxOpenConns(t *testing.T) {
+	db := fakeDB(concurrentTestDB())
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	reports := []MultiSheetRep{
+		{Params: RepParams{RepSheet: "First", Query: "medium", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Second", Query: "medium", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Third", Query: "fast", Logger: discardLogger{}}, DB: db},
+	}
+	setConcurrency(reports, 4)
+
+	fakeResetPeak()
+	if _, err := buildMultiSheetFromDB(context.Background(), reports); err != nil {
+		t.Fatal(err)
+	}
+	if peak := fakePeakQueries(); peak != 1 {
+		t.Errorf("%d queries run at the same time with MaxOpenConns 1", peak)
+	}
+}
+
+func TestConcurrentStrictMode(t *testing.T) {
+	db := fakeDB(concurrentTestDB())
+	defer db.Close()
+
+	reports := []MultiSheetRep{
+		{Params: RepParams{RepSheet: "Failed", Query: "missing", StrictMode: true, Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Slow", Query: "slow", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Fast", Query: "fast", Logger: discardLogger{}}, DB: db},
+	}
+	setConcurrency(reports, 2)
+
+	file, err := buildMultiSheetFromDB(context.Background(), reports)
+	var sheetErr *SheetError
+	if file != nil || !errors.As(err, &sheetErr) || sheetErr.Sheet != "Failed" {
+		t.Errorf("file = %v, err = %v, want StrictMode error of sheet Failed", file, err)
+	}
+}
+
+func TestConcurrentMultiSheet(t *testing.T) {
+	db := fakeDB(concurrentTestDB())
+	defer db.Close()
+
+	type nameRow struct{ Name CellStr }
+	var reports []MultiSheetRep
+	for _, q := range []string{"slow", "medium", "fast"} {
+		reports = append(reports, MultiSheetRep{Params: RepParams{RepSheet: q, Query: q, Logger: discardLogger{}}, Data: &SliceReport[nameRow]{}, DB: db})
+	}
+	setConcurrency(reports, 3)
+
+	file, err := buildMultiSheet(context.Background(), reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, q := range []string{"slow", "medium", "fast"} {
+		if file.Sheets[i].Name != q || file.Sheets[i].Cell(4, 0).Value != q {
+			t.Errorf("sheet %d = %q (%q), want %q", i+1, file.Sheets[i].Name, file.Sheets[i].Cell(4, 0).Value, q)
+		}
+	}
+}
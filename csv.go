@@ -0,0 +1,95 @@
+package xlsrpt
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+CSVOptions - Options for reports written as CSV (see OutputCSV), set on RepParams.CSV.
+
+CSV files have the column titles row followed by the data rows, as defined by RFC 4180 (CRLF line breaks,
+fields quoted when needed). Title, subtotal and footer rows are not written. Sheets of multiple sheets
+reports are written one after the other, with a new titles row when the columns change.
+
+Numbers are written with the value saved on the cell (not rounded to their column format), with no thousands
+separators or currency signs, and percents as fractions. Dates are written as 2006-01-02, adding the time
+(15:04:05) for values that have one, unless DateFormat (a time layout) is set.
+
+Encoding transforms the UTF-8 output, i.e. charmap.Windows1252.NewEncoder().Writer from golang.org/x/text.
+If the writer it returns is an io.Closer it is closed after the report is written (it must not close w).
+BOM starts the file with a byte order mark, so excel detects the encoding.
+*/
+type CSVOptions struct {
+	Delimiter  rune // ',' when not set
+	Decimal    rune // '.' when not set
+	DateFormat string
+	NoHeader   bool
+	BOM        bool
+	Encoding   func(w io.Writer) io.Writer
+}
+
+// writeCSV writes the data rows of the workbook sheets to w as CSV.
+func writeCSV(w io.Writer, file *xlsx.File, opts *CSVOptions) (err error) {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	if opts.Encoding != nil {
+		w = opts.Encoding(w)
+		if c, ok := w.(io.Closer); ok {
+			defer func() {
+				if cerr := c.Close(); err == nil {
+					err = cerr
+				}
+			}()
+		}
+	}
+	if opts.BOM {
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+	tf := textFormat{decimal: opts.Decimal, dateFormat: opts.DateFormat}
+
+	var header []string
+	for _, sheet := range file.Sheets {
+		for r, kind := range sheetRowKinds(sheet) {
+			var record []string
+			switch kind {
+			case headerRow:
+				record = rowText(sheet.Rows[r], tf)
+				if opts.NoHeader || slices.Equal(record, header) {
+					continue
+				}
+				header = record
+			case dataRow:
+				record = rowText(sheet.Rows[r], tf)
+			default:
+				continue
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// rowText returns the text of the cells of row.
+func rowText(row *xlsx.Row, tf textFormat) []string {
+	record := make([]string, len(row.Cells))
+	for c, cell := range row.Cells {
+		record[c] = tf.cellText(cell)
+	}
+	return record
+}
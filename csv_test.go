@@ -0,0 +1,224 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type csvTestRow struct {
+	Name    CellStr
+	Count   CellInt
+	Rate    CellDecimal
+	Share   CellPercent
+	Balance CellCurrency
+	Since   CellDate
+}
+
+func csvTestRows() []csvTestRow {
+	return []csvTestRow{
+		{"Smith, John", 3, 1234.5, 0.125, 1500.25, CellDate(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC))},
+		{`Say "hi"`, -2, -0.4, 1, -20, CellDate(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	rp := RepParams{
+		RepTitle:    "CSV",
+		RepCols:     []RepColumns{{Title: "Name"}, {Title: "Count"}, {Title: "Rate"}, {Title: "Share", Format: FormatPercent}, {Title: "Balance", SumFlag: true}, {Title: "Since"}},
+		AltBg:       true,
+		FooterLabel: "Total",
+		Output:      OutputCSV}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, csvTestRows()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Name,Count,Rate,Share,Balance,Since\r\n" +
+		"\"Smith, John\",3,1234.5,0.125,1500.25,2023-05-01\r\n" +
+		"\"Say \"\"hi\"\"\",-2,-0.4,1,-20,2024-01-02 15:04:05\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCSVFloat(t *testing.T) {
+	type amountRow struct {
+		Name   string
+		Amount float64 // CellDecimal, shown with no decimals
+	}
+	rows := []amountRow{{"First", 1234.56}, {"Second", 0.001}}
+
+	var buf bytes.Buffer
+	rp := RepParams{RepTitle: "Amounts", Output: OutputCSV, CSV: &CSVOptions{Delimiter: ';', Decimal: ','}}
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Name;Amount\r\nFirst;1234,56\r\nSecond;0,001\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCSVCurrency(t *testing.T) {
+	type priceRow struct{ Price CellCurrency }
+	rows := []priceRow{{19.99}, {0.1}, {-1234.57}}
+
+	var buf bytes.Buffer
+	rp := RepParams{RepTitle: "Prices", Output: OutputCSV}
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// CellCurrency values are float32, they must not be written with the digits of float64
+	want := "Price\r\n19.99\r\n0.1\r\n-1234.57\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
+
+// latin1Writer encodes the UTF-8 text written to it as ISO-8859-1.
+type latin1Writer struct {
+	w      io.Writer
+	closed bool
+}
+
+func (l *latin1Writer) Write(p []byte) (int, error) {
+	var b []byte
+	for _, r := range string(p) {
+		b = append(b, byte(r))
+	}
+	_, err := l.w.Write(b)
+	return len(p), err
+}
+
+func (l *latin1Writer) Close() error {
+	l.closed = true
+	return nil
+}
+
+func TestWriteCSVOptions(t *testing.T) {
+	var enc *latin1Writer
+	rp := RepParams{
+		RepTitle: "CSV",
+		RepCols:  []RepColumns{{Title: "Name"}, {Title: "Count"}, {Title: "Rate"}, {Title: "Share", Format: FormatPercent}, {Title: "Balance"}, {Title: "Since"}},
+		Output:   OutputCSV,
+		CSV: &CSVOptions{
+			Delimiter:  ';',
+			Decimal:    ',',
+			DateFormat: "02/01/2006",
+			NoHeader:   true,
+			Encoding: func(w io.Writer) io.Writer {
+				enc = &latin1Writer{w: w}
+				return enc
+			}}}
+	rows := []csvTestRow{{"Peña", 1, 2, 0.5, 3.5, CellDate(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC))}}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Pe\xf1a;1;2;0,5;3,5;01/05/2023\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+	if !enc.closed {
+		t.Error("encoding writer not closed")
+	}
+}
+
+func TestCSVFromDB(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"csvgroups": {
+			cols:  []string{"region", "amount"},
+			types: []string{"VARCHAR", "INT"},
+			rows: [][]driver.Value{
+				{[]byte("North"), "1"}, {[]byte("North"), "2"}, {[]byte("South"), "3"}}}})
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "groups.CSV")
+	rp := RepParams{
+		RepTitle: "Groups",
+		Query:    "csvgroups",
+		FilePath: path,
+		RepCols:  []RepColumns{{Title: "Region"}, {Title: "Amount", SumFlag: true}},
+		GroupBy:  []string{"region"},
+		CSV:      &CSVOptions{BOM: true},
+		Logger:   discardLogger{}}
+	if err := ExcelFromDB(rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Subtotal and footer rows are not written
+	want := "\uFEFFRegion,Amount\r\nNorth,1\r\nNorth,2\r\nSouth,3\r\n"
+	if string(got) != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
+
+func TestOutputFor(t *testing.T) {
+	tests := []struct {
+		out  Output
+		path string
+		want Output
+		file string
+	}{
+		{OutputAuto, "report.xlsx", OutputXLSX, "report.xlsx"},
+		{OutputAuto, "report", OutputXLSX, "report.xlsx"},
+		{OutputAuto, "report.Csv", OutputCSV, "report.Csv"},
+		{OutputCSV, "report", OutputCSV, "report.csv"},
+		{OutputCSV, "report.xlsx", OutputCSV, "report.xlsx.csv"},
+		{OutputXLSX, "report.csv", OutputXLSX, "report.csv.xlsx"},
+	}
+
+	for _, tt := range tests {
+		out := outputFor(tt.out, tt.path)
+		if out != tt.want {
+			t.Errorf("outputFor(%d, %q) = %d, want %d", tt.out, tt.path, out, tt.want)
+		}
+		if file := outputPath(tt.path, out, discardLogger{}); file != tt.file {
+			t.Errorf("outputPath(%q, %d) = %q, want %q", tt.path, out, file, tt.file)
+		}
+	}
+}
+
+func TestNumberText(t *testing.T) {
+	tests := []struct {
+		f    float64
+		code string
+		tf   textFormat
+		want string
+	}{
+		{1234.5, "general", textFormat{}, "1234.5"},
+		{1234.56, "#,##0", textFormat{}, "1234.56"},
+		{1234.5, "$#,##0.00", textFormat{}, "1234.5"},
+		{1234.5, "$#,##0.00", textFormat{decimal: ','}, "1234,5"},
+		{1234.5, "#,##0", textFormat{display: true}, "1,235"},
+		{1234.5, "$#,##0.00", textFormat{display: true}, "$1,234.50"},
+		{-1234.5, "$#,##0.00", textFormat{display: true, decimal: ','}, "-$1.234,50"},
+		{0.125, "0.00%", textFormat{}, "0.125"},
+		{0.125, "0.00%", textFormat{display: true}, "12.50%"},
+		{-0.001, "0.00", textFormat{display: true}, "0.00"},
+		{math.Copysign(0, -1), "general", textFormat{}, "0"},
+		{1234567, "0", textFormat{display: true}, "1234567"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tf.numberText(tt.f, tt.code); got != tt.want {
+			t.Errorf("numberText(%v, %q) = %q, want %q", tt.f, tt.code, got, tt.want)
+		}
+	}
+}
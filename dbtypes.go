@@ -0,0 +1,105 @@
+package xlsrpt
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// dbColumnFormat returns the Format for a column of type ct, using the database type name,
+// the scale of decimal columns and the driver scan type.
+// An empty format means the cell type is inferred from each value.
+func dbColumnFormat(ct *sql.ColumnType) string {
+	name := strings.TrimPrefix(typeName(ct), "UNSIGNED ")
+
+	switch name {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT", "INT2", "INT4", "INT8",
+		"SERIAL", "BIGSERIAL", "SMALLSERIAL", "YEAR", "PLS_INTEGER", "BINARY_INTEGER":
+		return FormatInt
+	case "DECIMAL", "NUMERIC", "NUMBER", "DEC":
+		if precision, scale, ok := ct.DecimalSize(); ok && precision > 0 {
+			return decimalFormat(scale)
+		}
+		return FormatNumeric
+	case "MONEY", "SMALLMONEY":
+		return FormatCurrency
+	case "FLOAT", "REAL", "DOUBLE", "DOUBLE PRECISION", "FLOAT4", "FLOAT8", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return FormatNumeric
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ",
+		"TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITHOUT TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
+		return FormatDate
+	case "CHAR", "VARCHAR", "VARCHAR2", "NCHAR", "NVARCHAR", "NVARCHAR2", "TEXT", "NTEXT", "TINYTEXT",
+		"MEDIUMTEXT", "LONGTEXT", "CLOB", "NCLOB", "BPCHAR", "CHARACTER", "CHARACTER VARYING",
+		"UUID", "UNIQUEIDENTIFIER", "ENUM", "SET", "XML", "JSON", "JSONB", "STRING":
+		return FormatText
+	}
+
+	return scanTypeFormat(ct.ScanType())
+}
+
+// scanTypeFormat returns the Format for a column with the given driver scan type.
+// Empty for types with no specific format (strings, bytes, interfaces).
+func scanTypeFormat(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(sql.NullTime{}):
+		return FormatDate
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}):
+		return FormatInt
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return FormatNumeric
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FormatInt
+	case reflect.Float32, reflect.Float64:
+		return FormatNumeric
+	}
+	return ""
+}
+
+// decimalFormat returns the excel number format for a decimal column with the given scale.
+func decimalFormat(scale int64) string {
+	if scale <= 0 {
+		return FormatInt
+	}
+	return "#,##0." + strings.Repeat("0", int(scale))
+}
+
+// queryColumns returns the report columns for the query columns names, formatted with formats.
+// Items of repCols are matched by Column name (ignoring case) or by position when Column is not set.
+func queryColumns(names []string, formats []string, repCols []RepColumns, log Logger) []column {
+	cols := make([]column, len(names))
+	for c, name := range names {
+		cols[c] = column{RepColumns: RepColumns{Title: name, Format: formats[c]}, field: c, name: name}
+	}
+
+	for i, rc := range repCols {
+		c := i
+		if rc.Column != "" {
+			c = -1
+			for j, name := range names {
+				if strings.EqualFold(name, rc.Column) {
+					c = j
+					break
+				}
+			}
+		}
+		if c < 0 || c >= len(cols) {
+			log.Warn("RepCols item doesn't match a query column", "column", rc.Column, "position", i)
+			continue
+		}
+		cols[c].RepColumns = overrideColumn(cols[c].RepColumns, rc)
+	}
+
+	return cols
+}
@@ -0,0 +1,155 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestGenSheetFromDBColumnTypes(t *testing.T) {
+	created := time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC)
+	db := fakeDB(map[string]fakeResult{
+		"coltypes": {
+			cols:  []string{"Zip", "Amount", "Qty", "Created", "Ratio"},
+			types: []string{"VARCHAR", "DECIMAL", "INT", "DATETIME", ""},
+			sizes: map[int][2]int64{1: {10, 3}},
+			rows: [][]driver.Value{
+				{"00123", "1234.5", "42", created, 0.25},
+				{nil, "2.125", "7", created, 1.5}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "Types", Query: "coltypes", NoTitleRow: true, Logger: discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	cells := file.Sheets[0].Rows[1].Cells
+	if cells[0].Value != "00123" || cells[0].Type() != xlsx.CellTypeString {
+		t.Errorf("VARCHAR cell = %q (%v), want untouched string", cells[0].Value, cells[0].Type())
+	}
+	if f, err := cells[1].Float(); err != nil || f != 1234.5 || cells[1].GetNumberFormat() != "#,##0.000" {
+		t.Errorf("DECIMAL cell = %q format %q", cells[1].Value, cells[1].GetNumberFormat())
+	}
+	if n, err := cells[2].Int(); err != nil || n != 42 {
+		t.Errorf("INT cell = %q", cells[2].Value)
+	}
+	if !cells[3].IsTime() {
+		t.Errorf("DATETIME cell is not a date")
+	}
+	if f, err := cells[4].Float(); err != nil || f != 0.25 {
+		t.Errorf("float cell = %q", cells[4].Value)
+	}
+}
+
+func TestGenSheetFromDBBytes(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"mysqlbytes": {
+			cols:  []string{"Id", "Balance", "Created", "Day", "Name", "Other"},
+			types: []string{"UNSIGNED BIGINT", "DECIMAL", "DATETIME", "DATE", "VARCHAR", "BLOB"},
+			sizes: map[int][2]int64{1: {12, 2}},
+			rows: [][]driver.Value{
+				{[]byte("18"), []byte("99.50"), []byte("2021-07-08 10:11:12"), []byte("2021-07-08"), []byte("007"), []byte("12")}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "MySQL", Query: "mysqlbytes", NoTitleRow: true, Logger: discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	cells := file.Sheets[0].Rows[1].Cells
+	if n, err := cells[0].Int(); err != nil || n != 18 {
+		t.Errorf("BIGINT cell = %q", cells[0].Value)
+	}
+	if f, err := cells[1].Float(); err != nil || f != 99.5 || cells[1].GetNumberFormat() != "#,##0.00" {
+		t.Errorf("DECIMAL cell = %q format %q", cells[1].Value, cells[1].GetNumberFormat())
+	}
+	for _, c := range []int{2, 3} {
+		got, err := cells[c].GetTime(false)
+		if err != nil || got.Year() != 2021 || got.Month() != 7 || got.Day() != 8 {
+			t.Errorf("date cell %d = %q (%v)", c, cells[c].Value, got)
+		}
+	}
+	if cells[4].Value != "007" {
+		t.Errorf("VARCHAR cell = %q", cells[4].Value)
+	}
+	if n, err := cells[5].Int(); err != nil || n != 12 {
+		t.Errorf("BLOB cell = %q, want value inferred from text", cells[5].Value)
+	}
+}
+
+func TestGenSheetFromDBRepCols(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"repcols": {
+			cols:  []string{"Name", "Balance", "Internal"},
+			types: []string{"VARCHAR", "FLOAT", "INT"},
+			rows: [][]driver.Value{
+				{"John", 10.5, int64(1)},
+				{"Jane", 20.25, int64(2)}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepSheet:   "RepCols",
+		Query:      "repcols",
+		NoTitleRow: true,
+		AutoFilter: true,
+		Logger:     discardLogger{},
+		RepCols: []RepColumns{
+			{Title: "Customer"},
+			{Column: "internal", Hidden: true},
+			{Column: "BALANCE", Title: "Customer Balance", Format: FormatCurrency, SumFlag: true}}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := file.Sheets[0]
+	for c, want := range []string{"Customer", "Customer Balance", "Internal"} {
+		if got := sheet.Cell(0, c).Value; got != want {
+			t.Errorf("title %d = %q, want %q", c, got, want)
+		}
+	}
+	if got := sheet.Cell(1, 1).GetNumberFormat(); got != "$#,##0.00" {
+		t.Errorf("Balance format = %q", got)
+	}
+	if !sheet.Col(2).Hidden {
+		t.Error("Internal column not hidden")
+	}
+	if got := sheet.Cell(3, 1).Formula(); got != "=SUBTOTAL(109,B2:B3)" {
+		t.Errorf("footer formula = %q", got)
+	}
+	if got := sheet.AutoFilter.BottomRightCell; got != "C3" {
+		t.Errorf("autofilter bottom right cell = %q, want C3", got)
+	}
+}
+
+func TestGenSheetFromDBMoney(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"money": {
+			cols:  []string{"Balance", "Price"},
+			types: []string{"MONEY", "DECIMAL"},
+			rows:  [][]driver.Value{{"12345678.91", "12345678.91"}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepSheet:   "Money",
+		Query:      "money",
+		NoTitleRow: true,
+		Logger:     discardLogger{},
+		RepCols:    []RepColumns{{Column: "Price", Format: FormatCurrency}}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Currency values must not be rounded to float32
+	for c, cell := range file.Sheets[0].Rows[1].Cells {
+		if cell.Value != "12345678.91" || cell.GetNumberFormat() != "$#,##0.00" {
+			t.Errorf("cell %d = %q format %q, want 12345678.91 as currency", c, cell.Value, cell.GetNumberFormat())
+		}
+	}
+}
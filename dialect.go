@@ -0,0 +1,226 @@
+package xlsrpt
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Dialect controls how the values returned by a database driver are added to the cells
+of ExcelFromDB and ExcelMultiSheetFromDB reports.
+
+Dialects are registered by driver type with RegisterDialect. Built-in dialects are registered for
+sqlite (mattn/go-sqlite3, modernc.org/sqlite), postgres (lib/pq, pgx), mssql (go-mssqldb),
+oracle (godror, go-ora, go-oci8) and mysql (go-sql-driver/mysql).
+BaseDialect is used for drivers with no dialect registered.
+*/
+type Dialect interface {
+	// ColumnFormat returns the Format for a query column, empty to infer the cell type from each value.
+	ColumnFormat(ct *sql.ColumnType) string
+
+	// Value converts a value returned by the driver for a column of type ct before it's added to a cell.
+	// Returning nil adds a NULL value.
+	Value(v interface{}, ct *sql.ColumnType) interface{}
+}
+
+/*
+BaseDialect is a configurable Dialect, it's used for drivers with no registered dialect
+and can be embedded to implement custom dialects:
+
+	xlsrpt.RegisterDialect("*mydriver.Driver", xlsrpt.BaseDialect{
+		Location:  time.Local,
+		BoolTypes: []string{"BOOLEAN"},
+		BoolTrue:  "Yes",
+		BoolFalse: "No"})
+*/
+type BaseDialect struct {
+	// Location is used to normalize dates, nil leaves them unchanged.
+	Location *time.Location
+
+	// Types sets the Format for a database type name (i.e. "MONEY": FormatCurrency).
+	// Types not found here use the default inference (based on type name, scale and scan type).
+	Types map[string]string
+
+	// BoolTypes are database type names whose integer or text values ("1", "t", "true") are booleans.
+	BoolTypes []string
+
+	// BoolTrue and BoolFalse are the cell text for booleans, boolean cells are used when both are empty.
+	BoolTrue, BoolFalse string
+}
+
+// ColumnFormat implements Dialect.
+func (d BaseDialect) ColumnFormat(ct *sql.ColumnType) string {
+	if format, ok := d.Types[typeName(ct)]; ok {
+		return format
+	}
+	if d.isBoolType(ct) {
+		return ""
+	}
+	return dbColumnFormat(ct)
+}
+
+// Value implements Dialect.
+func (d BaseDialect) Value(v interface{}, ct *sql.ColumnType) interface{} {
+	switch x := v.(type) {
+	case time.Time:
+		if d.Location != nil {
+			return x.In(d.Location)
+		}
+	case bool:
+		return d.boolValue(x)
+	case int64:
+		if d.isBoolType(ct) {
+			return d.boolValue(x != 0)
+		}
+	case []byte:
+		if d.isBoolType(ct) {
+			if b, err := strconv.ParseBool(string(x)); err == nil {
+				return d.boolValue(b)
+			}
+		}
+	case string:
+		if d.isBoolType(ct) {
+			if b, err := strconv.ParseBool(x); err == nil {
+				return d.boolValue(b)
+			}
+		}
+	}
+	return v
+}
+
+func (d BaseDialect) isBoolType(ct *sql.ColumnType) bool {
+	name := typeName(ct)
+	for _, t := range d.BoolTypes {
+		if strings.EqualFold(t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d BaseDialect) boolValue(b bool) interface{} {
+	if d.BoolTrue == "" && d.BoolFalse == "" {
+		return b
+	}
+	if b {
+		return d.BoolTrue
+	}
+	return d.BoolFalse
+}
+
+// mysqlDialect decodes dates returned as bytes (when parseTime is not set on the DSN)
+// and returns zero dates (0000-00-00) as NULL.
+type mysqlDialect struct {
+	BaseDialect
+}
+
+func (d mysqlDialect) Value(v interface{}, ct *sql.ColumnType) interface{} {
+	if b, ok := v.([]byte); ok && d.ColumnFormat(ct) == FormatDate {
+		if strings.HasPrefix(string(b), "0000-00-00") {
+			return nil
+		}
+		if t, ok := parseTime(reflect.ValueOf(string(b))); ok {
+			v = t
+		}
+	}
+	return d.BaseDialect.Value(v, ct)
+}
+
+// mssqlDialect returns UNIQUEIDENTIFIER values (returned as bytes) as text.
+type mssqlDialect struct {
+	BaseDialect
+}
+
+func (d mssqlDialect) Value(v interface{}, ct *sql.ColumnType) interface{} {
+	if b, ok := v.([]byte); ok && len(b) == 16 && typeName(ct) == "UNIQUEIDENTIFIER" {
+		// SQL Server stores the first three groups in little endian order
+		return fmt.Sprintf("%X-%X-%X-%X-%X",
+			[]byte{b[3], b[2], b[1], b[0]}, []byte{b[5], b[4]}, []byte{b[7], b[6]}, b[8:10], b[10:])
+	}
+	return d.BaseDialect.Value(v, ct)
+}
+
+// postgresDialect parses MONEY values, which are returned as text with currency symbols.
+type postgresDialect struct {
+	BaseDialect
+}
+
+func (d postgresDialect) Value(v interface{}, ct *sql.ColumnType) interface{} {
+	if typeName(ct) == "MONEY" {
+		var s string
+		switch x := v.(type) {
+		case []byte:
+			s = string(x)
+		case string:
+			s = x
+		default:
+			return d.BaseDialect.Value(v, ct)
+		}
+		negative := strings.ContainsAny(s, "-(")
+		s = strings.Map(func(r rune) rune {
+			if (r >= '0' && r <= '9') || r == '.' {
+				return r
+			}
+			return -1
+		}, s)
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			if negative {
+				f = -f
+			}
+			return f
+		}
+	}
+	return d.BaseDialect.Value(v, ct)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		"*sqlite3.SQLiteDriver":  BaseDialect{BoolTypes: []string{"BOOLEAN", "BOOL"}},
+		"*sqlite.Driver":         BaseDialect{BoolTypes: []string{"BOOLEAN", "BOOL"}},
+		"*pq.Driver":             postgresDialect{BaseDialect{Types: map[string]string{"MONEY": FormatCurrency}}},
+		"*stdlib.Driver":         postgresDialect{BaseDialect{Types: map[string]string{"MONEY": FormatCurrency}}},
+		"*mssql.Driver":          mssqlDialect{BaseDialect{Types: map[string]string{"UNIQUEIDENTIFIER": FormatText}}},
+		"*godror.drv":            BaseDialect{Types: map[string]string{"ROWID": FormatText, "UROWID": FormatText, "LONG": FormatText}},
+		"*go_ora.OracleDriver":   BaseDialect{Types: map[string]string{"ROWID": FormatText, "UROWID": FormatText, "LONG": FormatText}},
+		"*oci8.OCI8DriverStruct": BaseDialect{Types: map[string]string{"ROWID": FormatText, "UROWID": FormatText, "LONG": FormatText}},
+		"*mysql.MySQLDriver":     mysqlDialect{BaseDialect{BoolTypes: []string{"BOOL", "BOOLEAN"}}},
+	}
+)
+
+/*
+RegisterDialect sets the Dialect used for the driver with type driverType,
+replacing the built-in dialect for that driver if any.
+
+driverType is the type name of the driver as printed by fmt.Sprintf("%T", db.Driver()),
+i.e. "*mysql.MySQLDriver".
+*/
+func RegisterDialect(driverType string, d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[driverType] = d
+}
+
+// dialectFor returns the Dialect for the driver of db.
+func dialectFor(db *sql.DB) Dialect {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	if d, ok := dialects[fmt.Sprintf("%T", db.Driver())]; ok {
+		return d
+	}
+	return BaseDialect{}
+}
+
+// typeName returns the database type name of ct in upper case and without length or precision.
+func typeName(ct *sql.ColumnType) string {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	return name
+}
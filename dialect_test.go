@@ -0,0 +1,91 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+// dialectSheet generates a sheet for result using dialect d and returns the cells of the first row.
+func dialectSheet(t *testing.T, query string, result fakeResult, d Dialect) []*xlsx.Cell {
+	t.Helper()
+	db := fakeDB(map[string]fakeResult{query: result})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "Dialect", Query: query, NoTitleRow: true, Logger: discardLogger{}}
+	if d != nil {
+		rp.Options = &Options{Dialect: d}
+	}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	return file.Sheets[0].Rows[1].Cells
+}
+
+func TestRegisterDialect(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	RegisterDialect("xlsrpt.fakeDriver", BaseDialect{
+		Location:  ny,
+		BoolTypes: []string{"BOOLEAN"},
+		BoolTrue:  "Yes",
+		BoolFalse: "No",
+		Types:     map[string]string{"CODE": FormatText}})
+	defer func() {
+		dialectsMu.Lock()
+		delete(dialects, "xlsrpt.fakeDriver")
+		dialectsMu.Unlock()
+	}()
+
+	cells := dialectSheet(t, "registered", fakeResult{
+		cols:  []string{"Created", "Active", "Code"},
+		types: []string{"TIMESTAMP", "BOOLEAN", "CODE"},
+		rows:  [][]driver.Value{{time.Date(2021, 1, 1, 3, 0, 0, 0, time.UTC), int64(1), int64(42)}}}, nil)
+
+	got, err := cells[0].GetTime(false)
+	if err != nil || got.Day() != 31 || got.Hour() != 22 {
+		t.Errorf("date cell = %v, want 2020-12-31 22:00 (New York)", got)
+	}
+	if cells[1].Value != "Yes" {
+		t.Errorf("bool cell = %q, want %q", cells[1].Value, "Yes")
+	}
+	if cells[2].Value != "42" || cells[2].Type() != xlsx.CellTypeString {
+		t.Errorf("CODE cell = %q (%v), want text", cells[2].Value, cells[2].Type())
+	}
+}
+
+func TestBuiltinDialects(t *testing.T) {
+	guid := []byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0}
+	cells := dialectSheet(t, "mssql", fakeResult{
+		cols:  []string{"Id"},
+		types: []string{"UNIQUEIDENTIFIER"},
+		rows:  [][]driver.Value{{guid}}}, dialects["*mssql.Driver"])
+	if want := "12345678-1234-5678-1234-56789ABCDEF0"; cells[0].Value != want {
+		t.Errorf("mssql UNIQUEIDENTIFIER = %q, want %q", cells[0].Value, want)
+	}
+
+	cells = dialectSheet(t, "postgres", fakeResult{
+		cols:  []string{"Amount"},
+		types: []string{"MONEY"},
+		rows:  [][]driver.Value{{[]byte("-$1,234.50")}}}, dialects["*pq.Driver"])
+	if f, err := cells[0].Float(); err != nil || f != -1234.5 {
+		t.Errorf("postgres MONEY = %q", cells[0].Value)
+	}
+
+	cells = dialectSheet(t, "mysql", fakeResult{
+		cols:  []string{"Created", "Active"},
+		types: []string{"DATETIME", "BOOLEAN"},
+		rows:  [][]driver.Value{{[]byte("0000-00-00 00:00:00"), []byte("1")}}}, dialects["*mysql.MySQLDriver"])
+	if cells[0].Value != "" {
+		t.Errorf("mysql zero date = %q, want NULL", cells[0].Value)
+	}
+	if !cells[1].Bool() {
+		t.Errorf("mysql BOOLEAN = %q, want true", cells[1].Value)
+	}
+}
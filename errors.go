@@ -0,0 +1,20 @@
+package xlsrpt
+
+import "fmt"
+
+// SheetError is returned when a report sheet could not be generated.
+// When several sheets fail, the returned error joins one SheetError for each of them,
+// use errors.As() to retrieve them.
+type SheetError struct {
+	Sheet string // Name of the sheet that failed
+	Err   error  // Underlying error
+}
+
+func (e *SheetError) Error() string {
+	return fmt.Sprintf("sheet %q: %v", e.Sheet, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *SheetError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,57 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var errConnReset = errors.New("connection reset")
+
+func TestRowsError(t *testing.T) {
+	// Rows fail after the third one
+	res := nameTestDB(5)
+	res.err, res.errAt = errConnReset, 3
+	db := fakeDB(map[string]fakeResult{"failing": res})
+	defer db.Close()
+
+	rp := RepParams{RepSheet: "Failing", Query: "failing", Logger: discardLogger{}}
+	write := map[string]func(w *bytes.Buffer) error{
+		"WriteExcelFromDB": func(w *bytes.Buffer) error {
+			return WriteExcelFromDB(context.Background(), w, rp, db)
+		},
+		"WriteExcelFromDBStream": func(w *bytes.Buffer) error {
+			return WriteExcelFromDBStream(context.Background(), w, rp, db)
+		},
+		"WriteExcelReport": func(w *bytes.Buffer) error {
+			return WriteExcelReport(context.Background(), w, rp, &SliceReport[nameTestRow]{}, db)
+		},
+	}
+	for name, fn := range write {
+		var buf bytes.Buffer
+		err := fn(&buf)
+		var sheetErr *SheetError
+		if !errors.As(err, &sheetErr) || sheetErr.Sheet != "Failing" || !errors.Is(err, errConnReset) {
+			t.Errorf("%s: err = %v, want connection reset error of sheet Failing", name, err)
+		}
+	}
+}
+
+func TestNoSheetsNotSaved(t *testing.T) {
+	db := fakeDB(nil)
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "missing.xlsx")
+	rp := RepParams{RepTitle: "Missing", Query: "missing", FilePath: path, Logger: discardLogger{}}
+	err := ExcelReport(rp, &SliceReport[nameTestRow]{}, db)
+	var sheetErr *SheetError
+	if !errors.As(err, &sheetErr) || sheetErr.Sheet != "Missing" {
+		t.Errorf("err = %v, want query error of sheet Missing", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file of failed report was written")
+	}
+}
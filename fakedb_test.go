@@ -0,0 +1,175 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// fakeResult is a query result returned by the fake driver used on tests.
+type fakeResult struct {
+	cols  []string
+	types []string         // DatabaseTypeName of each column (optional)
+	sizes map[int][2]int64 // Precision and scale of decimal columns (optional)
+	rows  [][]driver.Value
+	delay time.Duration // Time taken by the query (optional)
+	err   error         // Returned by Next instead of row number errAt (optional)
+	errAt int
+	next  func(pos int) // Called by Next before reading row number pos (optional)
+}
+
+var (
+	fakeMu      sync.Mutex
+	fakeResults = make(map[string]fakeResult)
+	fakeActive  int // Queries with open rows
+	fakePeak    int // Maximum of fakeActive since the last fakeResetPeak
+
+	// Arguments of the last run of each query
+	fakeArgs = make(map[string][]driver.NamedValue)
+)
+
+// fakeResetPeak resets the maximum number of queries with open rows at the same time.
+func fakeResetPeak() {
+	fakeMu.Lock()
+	fakePeak = fakeActive
+	fakeMu.Unlock()
+}
+
+// fakeQueryArgs returns the arguments received by the last run of query.
+func fakeQueryArgs(query string) []driver.NamedValue {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return fakeArgs[query]
+}
+
+// fakePeakQueries returns the maximum number of queries with open rows at the same time.
+func fakePeakQueries() int {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return fakePeak
+}
+
+func init() {
+	sql.Register("xlsrptfake", fakeDriver{})
+}
+
+// fakeDB returns a *sql.DB where each query returns the result registered for it.
+func fakeDB(results map[string]fakeResult) *sql.DB {
+	fakeMu.Lock()
+	for q, r := range results {
+		fakeResults[q] = r
+	}
+	fakeMu.Unlock()
+
+	db, err := sql.Open("xlsrptfake", "")
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ query string }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), nil)
+}
+func (s fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	fakeMu.Lock()
+	fakeArgs[s.query] = args
+	r, ok := fakeResults[s.query]
+	if ok {
+		fakeActive++
+		if fakeActive > fakePeak {
+			fakePeak = fakeActive
+		}
+	}
+	fakeMu.Unlock()
+	if !ok {
+		return nil, errors.New("unknown query: " + s.query)
+	}
+
+	rows := &fakeRows{ctx: ctx, fakeResult: r}
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		rows.Close()
+		return nil, ctx.Err()
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	fakeResult
+	ctx    context.Context
+	pos    int
+	closed bool
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error {
+	fakeMu.Lock()
+	if !r.closed {
+		r.closed = true
+		fakeActive--
+	}
+	fakeMu.Unlock()
+	return nil
+}
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next != nil {
+		r.next(r.pos)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if r.err != nil && r.pos == r.errAt {
+		return r.err
+	}
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.types) {
+		return r.types[index]
+	}
+	return ""
+}
+
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	for _, row := range r.rows {
+		if row[index] != nil {
+			return reflect.TypeOf(row[index])
+		}
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *fakeRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	size, ok := r.sizes[index]
+	return size[0], size[1], ok
+}
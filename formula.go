@@ -0,0 +1,298 @@
+package xlsrpt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tealeg/xlsx"
+)
+
+// distinctFormula matches the formula used for AggCountDistinct totals.
+var distinctFormula = regexp.MustCompile(`^SUMPRODUCT\(\(([A-Z]+[0-9]+:[A-Z]+[0-9]+)<>""\)/COUNTIF\(([A-Z]+[0-9]+:[A-Z]+[0-9]+),([A-Z]+[0-9]+:[A-Z]+[0-9]+)&""\)\)$`)
+
+// rangeFunctions are the functions that can be used on evaluated formulas, with their SUBTOTAL function number.
+var rangeFunctions = map[string]int{
+	"AVERAGE": 1,
+	"COUNT":   2,
+	"COUNTA":  3,
+	"MAX":     4,
+	"MIN":     5,
+	"SUM":     9,
+}
+
+/*
+evalFormula returns the value of a total formula of a report sheet, for formats where formulas can't run.
+
+Footer and subtotal formulas are supported (see RepColumns.Aggregate) along with custom formulas made of
+numbers, cell references, + - * / operators, parentheses and the SUBTOTAL, SUM, AVERAGE, COUNT, COUNTA,
+MIN and MAX functions over ranges. Cells holding formulas are skipped on ranges, as SUBTOTAL does with the
+subtotals on its range. false is returned when the formula can't be evaluated, or when it refers to itself.
+*/
+func evalFormula(sheet *xlsx.Sheet, formula string) (float64, bool) {
+	return evalFormulaOf(sheet, formula, make(map[*xlsx.Cell]bool))
+}
+
+// evalFormulaOf evaluates formula, visiting holds the cells whose formulas are being evaluated.
+func evalFormulaOf(sheet *xlsx.Sheet, formula string, visiting map[*xlsx.Cell]bool) (float64, bool) {
+	formula = strings.TrimPrefix(strings.ReplaceAll(formula, " ", ""), "=")
+
+	if m := distinctFormula.FindStringSubmatch(formula); m != nil && m[1] == m[2] && m[2] == m[3] {
+		rng, ok := parseRange(m[1])
+		if !ok {
+			return 0, false
+		}
+		distinct := make(map[string]bool)
+		rng.each(sheet, func(cell *xlsx.Cell) {
+			distinct[cell.Value] = true
+		})
+		return float64(len(distinct)), true
+	}
+
+	p := &formulaParser{sheet: sheet, s: strings.ToUpper(formula), visiting: visiting}
+	v, ok := p.expr()
+	if !ok || p.pos != len(p.s) {
+		return 0, false
+	}
+	return v, true
+}
+
+// formulaParser evaluates a formula, see evalFormula.
+type formulaParser struct {
+	sheet    *xlsx.Sheet
+	s        string
+	pos      int
+	visiting map[*xlsx.Cell]bool // Cells referenced by formulas being evaluated, to detect cycles
+}
+
+// expr evaluates terms joined by + and -.
+func (p *formulaParser) expr() (float64, bool) {
+	v, ok := p.term()
+	for ok && p.pos < len(p.s) && (p.s[p.pos] == '+' || p.s[p.pos] == '-') {
+		op := p.s[p.pos]
+		p.pos++
+		var t float64
+		if t, ok = p.term(); op == '+' {
+			v += t
+		} else {
+			v -= t
+		}
+	}
+	return v, ok
+}
+
+// term evaluates factors joined by * and /.
+func (p *formulaParser) term() (float64, bool) {
+	v, ok := p.factor()
+	for ok && p.pos < len(p.s) && (p.s[p.pos] == '*' || p.s[p.pos] == '/') {
+		op := p.s[p.pos]
+		p.pos++
+		var f float64
+		f, ok = p.factor()
+		switch {
+		case op == '*':
+			v *= f
+		case f == 0:
+			return 0, false
+		default:
+			v /= f
+		}
+	}
+	return v, ok
+}
+
+// factor evaluates a number, a cell reference, a function or an expression between parentheses.
+func (p *formulaParser) factor() (float64, bool) {
+	if p.pos >= len(p.s) {
+		return 0, false
+	}
+
+	switch c := p.s[p.pos]; {
+	case c == '-' || c == '+':
+		p.pos++
+		v, ok := p.factor()
+		if c == '-' {
+			v = -v
+		}
+		return v, ok
+	case c == '(':
+		p.pos++
+		v, ok := p.expr()
+		if !ok || !p.consume(')') {
+			return 0, false
+		}
+		return v, true
+	case c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.s) && (p.s[p.pos] == '.' || unicode.IsDigit(rune(p.s[p.pos]))) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		return v, err == nil
+	}
+
+	name := p.name()
+	if name == "" {
+		return 0, false
+	}
+	if p.consume('(') {
+		return p.function(name)
+	}
+	rng, ok := parseRange(name)
+	if !ok || rng.col1 != rng.col2 || rng.row1 != rng.row2 {
+		return 0, false
+	}
+	cell := sheetCell(p.sheet, rng.col1, rng.row1)
+	if cell != nil && cell.Formula() != "" {
+		if p.visiting[cell] {
+			return 0, false
+		}
+		p.visiting[cell] = true
+		defer delete(p.visiting, cell)
+		return evalFormulaOf(p.sheet, cell.Formula(), p.visiting)
+	}
+	if cell == nil || cell.Value == "" {
+		return 0, true
+	}
+	v, err := cell.Float()
+	return v, err == nil
+}
+
+// function evaluates the arguments of the function name and the function itself.
+func (p *formulaParser) function(name string) (float64, bool) {
+	fn, ok := rangeFunctions[name]
+	if name == "SUBTOTAL" {
+		var n float64
+		if n, ok = p.expr(); !ok || !p.consume(',') {
+			return 0, false
+		}
+		fn = int(n)
+	}
+	if !ok {
+		return 0, false
+	}
+
+	rng, ok := parseRange(p.name())
+	if !ok || !p.consume(')') {
+		return 0, false
+	}
+	return rng.aggregate(p.sheet, fn)
+}
+
+// name returns the function name or cell reference at the current position, $ signs are removed.
+func (p *formulaParser) name() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := rune(p.s[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '$' && c != ':' {
+			break
+		}
+		p.pos++
+	}
+	return strings.ReplaceAll(p.s[start:p.pos], "$", "")
+}
+
+// consume skips c if it is at the current position.
+func (p *formulaParser) consume(c byte) bool {
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// cellRange is a range of cells, columns are zero based and rows as shown by excel.
+type cellRange struct {
+	col1, row1, col2, row2 int
+}
+
+// parseRange parses a cell reference (i.e. B2) or a range of cells (i.e. B2:B9).
+func parseRange(ref string) (cellRange, bool) {
+	var rng cellRange
+	ref1, ref2, found := strings.Cut(ref, ":")
+	if !found {
+		ref2 = ref1
+	}
+	var ok1, ok2 bool
+	rng.col1, rng.row1, ok1 = parseCellRef(ref1)
+	rng.col2, rng.row2, ok2 = parseCellRef(ref2)
+	return rng, ok1 && ok2 && rng.col1 <= rng.col2 && rng.row1 <= rng.row2
+}
+
+// parseCellRef returns the column and row of a cell reference, see CellRef.
+func parseCellRef(ref string) (col, row int, ok bool) {
+	i := strings.IndexFunc(ref, unicode.IsDigit)
+	if i <= 0 {
+		return 0, 0, false
+	}
+	col, err := ColumnIndex(ref[:i])
+	if err != nil {
+		return 0, 0, false
+	}
+	row, err = strconv.Atoi(ref[i:])
+	return col, row, err == nil && row > 0
+}
+
+// sheetCell returns the cell of the sheet at col and row (as shown by excel), nil if it doesn't exist.
+func sheetCell(sheet *xlsx.Sheet, col, row int) *xlsx.Cell {
+	if row > len(sheet.Rows) || sheet.Rows[row-1] == nil || col >= len(sheet.Rows[row-1].Cells) {
+		return nil
+	}
+	return sheet.Rows[row-1].Cells[col]
+}
+
+// each calls fn for the non empty cells of the range that don't hold a formula.
+func (rng cellRange) each(sheet *xlsx.Sheet, fn func(cell *xlsx.Cell)) {
+	for row := rng.row1; row <= rng.row2; row++ {
+		for col := rng.col1; col <= rng.col2; col++ {
+			if cell := sheetCell(sheet, col, row); cell != nil && cell.Value != "" && cell.Formula() == "" {
+				fn(cell)
+			}
+		}
+	}
+}
+
+// aggregate returns the SUBTOTAL function fn (only AVERAGE, COUNT, COUNTA, MAX, MIN and SUM are supported) of the range.
+func (rng cellRange) aggregate(sheet *xlsx.Sheet, fn int) (float64, bool) {
+	var count, nums int
+	var sum, lo, hi float64
+
+	rng.each(sheet, func(cell *xlsx.Cell) {
+		count++
+		if cell.Type() != xlsx.CellTypeNumeric {
+			return
+		}
+		f, err := cell.Float()
+		if err != nil {
+			return
+		}
+		if nums == 0 || f < lo {
+			lo = f
+		}
+		if nums == 0 || f > hi {
+			hi = f
+		}
+		nums++
+		sum += f
+	})
+
+	switch fn % 100 {
+	case 1:
+		if nums == 0 {
+			return 0, false
+		}
+		return sum / float64(nums), true
+	case 2:
+		return float64(nums), true
+	case 3:
+		return float64(count), true
+	case 4:
+		return hi, true
+	case 5:
+		return lo, true
+	case 9:
+		return sum, true
+	}
+	return 0, false
+}
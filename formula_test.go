@@ -0,0 +1,80 @@
+package xlsrpt
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestEvalFormula(t *testing.T) {
+	file := xlsx.NewFile()
+	sheet, _ := file.AddSheet("Formulas")
+	for _, v := range []interface{}{"Amount", 10, 20, "n/a", 30.5, 20} {
+		cell := sheet.AddRow().AddCell()
+		if s, ok := v.(string); ok {
+			cell.SetString(s)
+		} else {
+			cell.SetValue(v)
+		}
+	}
+	// A subtotal on the range, skipped by the formulas
+	cell := sheet.AddRow().AddCell()
+	cell.SetFloatWithFormat(0, "0")
+	cell.SetFormula("=SUBTOTAL(109,A2:A6)")
+	// Formulas that refer to themselves
+	cell = sheet.AddRow().AddCell()
+	cell.SetFormula("=A8+1")
+	cell = sheet.AddRow().AddCell()
+	cell.SetFormula("=A7+A10")
+	cell = sheet.AddRow().AddCell()
+	cell.SetFormula("=A9*2")
+
+	tests := []struct {
+		formula string
+		want    float64
+		ok      bool
+	}{
+		{"=SUBTOTAL(109,A2:A7)", 80.5, true},
+		{"=SUBTOTAL(101,A2:A7)", 20.125, true},
+		{"=SUBTOTAL(103,A2:A7)", 5, true},
+		{"=SUBTOTAL(102,A2:A7)", 4, true},
+		{"=SUBTOTAL(104,A2:A7)", 30.5, true},
+		{"=SUBTOTAL(105,$A$2:$A$7)", 10, true},
+		{`=SUMPRODUCT((A2:A7<>"")/COUNTIF(A2:A7,A2:A7&""))`, 4, true},
+		{"=SUBTOTAL(109,A2:A7)*0.16", 12.88, true},
+		{"=(sum(A2:A3) - A5) / 2", -0.25, true},
+		{"=A4*2", 0, false},
+		{"=A7+1", 81.5, true},
+		{"=-A2*-2", 20, true},
+		{"=SUBTOTAL(109,A2:A7)/0", 0, false},
+		{"=MEDIAN(A2:A7)", 0, false},
+		{"=SUBTOTAL(107,A2:A7)", 0, false},
+		{"=A2+", 0, false},
+		{"=A8+1", 0, false},
+		{"=A9", 0, false},
+		{"=A7+A7", 161, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := evalFormula(sheet, tt.formula)
+		if ok != tt.ok || (ok && (got-tt.want > 1e-9 || tt.want-got > 1e-9)) {
+			t.Errorf("evalFormula(%q) = %v, %v, want %v, %v", tt.formula, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormulaSelfReference(t *testing.T) {
+	type amountRow struct{ Amount float64 }
+	rows := []amountRow{{1}, {2}}
+
+	// The footer formula refers to the footer cell, it is left empty instead of recursing forever
+	rp := RepParams{RepTitle: "Cycle", NoTitleRow: true, RepCols: []RepColumns{{Title: "Amount", Formula: "=SUBTOTAL(109,{range})+A4"}}}
+	for _, out := range []Output{OutputHTML, OutputODS, OutputJSON} {
+		rp.Output = out
+		if err := WriteExcelFromSlice(context.Background(), io.Discard, rp, rows); err != nil {
+			t.Errorf("output %d: %v", out, err)
+		}
+	}
+}
@@ -3,35 +3,113 @@
 package xlsrpt
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"reflect"
 	"regexp"
 	"sort"
-	"strconv"
 	"time"
 
 	"github.com/tealeg/xlsx"
 )
 
-// RepColumns - Report Columns Definition.
+/*
+RepColumns - Report Columns Definition.
+
+Format can be one of the Format constants or an excel number format (i.e. "0.000").
+Width is the column width (28 when not set), Hidden columns are added to the sheet but not shown.
+
+The footer row shows the Aggregate of the column (one of the Agg constants), SumFlag is the same as AggSum.
+Formula can be used instead for a custom footer formula, "{range}" is replaced by the range of the column data
+(i.e. "=SUBTOTAL(109,{range})*0.16"). TotalFormat is the number format of the footer cell, when not set
+the column Format is used (currency for sums of columns with no Format).
+
+For struct based reports columns can also be defined with struct tags on the row struct fields:
+
+	type customer struct {
+		Name     xlsrpt.CellStr     `xlsrpt:"title=Customer Name,width=40"`
+		Balance  xlsrpt.CellDecimal `xlsrpt:"title=Customer Balance,format=currency,sum"`
+		Internal int                `xlsrpt:"-"`
+	}
+
+Tag options are title, format (formats with commas are not allowed), sum (same as SumFlag), agg (Aggregate),
+totalformat, width and hidden.
+Fields tagged "-" are not added to the report, the field name is used as title when not set.
+When RepParams.RepCols is set, its items override (by position) the columns defined by the struct.
+
+For ExcelFromDB reports, columns are defined by the query: the column name is used as title and the format is
+inferred from the column type. RepCols items override them, matched by Column (the query column name, ignoring case)
+or by position when Column is not set.
+*/
 type RepColumns struct {
-	Title   string
-	SumFlag bool
+	Title       string
+	SumFlag     bool
+	Format      string
+	Width       float64
+	Hidden      bool
+	Column      string
+	Aggregate   string
+	Formula     string
+	TotalFormat string
 }
 
 // RepParams - Parameters for Report Generation.
+//
+// QueryArgs are passed to the driver along with Query, so placeholders (?, $1, :1, @p1 depending
+// on the driver) can be used instead of building the query by hand. Use sql.Named() for named parameters.
+//
+// FooterLabel is shown on the first column of the footer row (i.e. "Total") when that column has no aggregate.
+//
+// GroupBy lists the columns (outermost first) used to group the rows, matched by title or by struct field
+// or query column name. Rows must be ordered by those columns (i.e. ORDER BY region, branch). A subtotal row
+// with the aggregates of the columns (see RepColumns) is added after each group, showing the group value
+// followed by FooterLabel, and the footer row becomes the grand total. Rows get excel outline levels so
+// groups can be collapsed. Custom formulas include the subtotal rows on their range, and AggCountDistinct
+// can't be used with GroupBy (the report fails).
+//
+// When Pivot is set the sheet is a cross-tab of the rows instead (see Pivot), GroupBy is not used.
+//
+// Overflow defines what to do on ExcelFromDB reports with more rows than an excel sheet allows (1,048,576).
+// By default rows continue on new sheets named after RepSheet (i.e. "Sales (2)", "Sales (3)") with the same
+// title and header rows and their own footer totals. OverflowGrandTotal adds a "Grand Total" row with the
+// totals of all the sheets at the end, and OverflowError makes the report fail with ErrRowLimit instead.
+// Struct based reports always fail with ErrRowLimit.
+//
+// Output is the file format of the report (see Output), chosen by the FilePath extension when not set
+// (".csv" for CSV, ".html" for HTML, ".ods" for OpenDocument, ".json" and ".ndjson" for JSON, xlsx otherwise).
+// Write* functions only use Output, and stream functions (i.e. ExcelFromDBStream) always write xlsx
+// (those saving a file fail when another format is chosen).
+// CSV, HTML and JSON set the options of those formats. On multiple sheets reports the Output and format
+// options of the first report are used.
+//
+// By default a sheet that fails is reported in the returned error and the rest of the workbook is still written.
+// When StrictMode is set, a failure on the sheet aborts the whole workbook and no file is written.
+//
+// Logger receives diagnostic messages for the report, see Logger for details.
+// Options holds the behavior configuration for the report, package level variables are used when nil.
 type RepParams struct {
-	RepTitle   string
-	RepSheet   string
-	RepCols    []RepColumns
-	Query      string
-	FilePath   string
-	AltBg      bool
-	AutoFilter bool
-	NoTitleRow bool
+	RepTitle    string
+	RepSheet    string
+	RepCols     []RepColumns
+	Query       string
+	QueryArgs   []interface{}
+	FilePath    string
+	AltBg       bool
+	AutoFilter  bool
+	NoTitleRow  bool
+	FooterLabel string
+	GroupBy     []string
+	Pivot       *Pivot
+	Overflow    OverflowMode
+	Output      Output
+	CSV         *CSVOptions
+	HTML        *HTMLOptions
+	JSON        *JSONOptions
+	StrictMode  bool
+	Logger      Logger
+	Options     *Options
 }
 
 // MultiSheetRep type is used for multiple sheets reports.
@@ -54,199 +132,221 @@ type ReportData interface {
 }
 
 // Vervose can be used to print information about Excel File generation when set to <true>.
+// Only used for reports with no Logger set, default for Options.Vervose.
 var Vervose bool
 
 // Debug can be used to print debug information about Excel File generation when set to <true>.
+// Only used for reports with no Logger set, default for Options.Debug.
 var Debug bool
 
 // ExcelReport generates excel report using a datamap that should be loaded by your implementation of LoadRows() function
 func ExcelReport(rp RepParams, rptData ReportData, db *sql.DB) error {
-	var file *xlsx.File
-
-	file = xlsx.NewFile()
-
-	rows, err := db.Query(rp.Query)
-	if err != nil {
-		return err
-	}
-
-	rptData.LoadRows(rows)
-	rows.Close()
+	return ExcelReportContext(context.Background(), rp, rptData, db)
+}
 
+// ExcelReportContext is like ExcelReport but uses ctx for the query and report generation.
+// If ctx is done before the report is completed, ctx.Err() is returned and no file is written.
+func ExcelReportContext(ctx context.Context, rp RepParams, rptData ReportData, db *sql.DB) error {
 	if rp.FilePath == "" {
-		rp.FilePath = rp.RepTitle + ".xlsx"
-	} else {
-		match, _ := regexp.MatchString(`(?m)([a-zA-Z0-9\s_\\.\-\(\):])+(.xls|.xlsx)$`, rp.FilePath)
-		if !match {
-			rp.FilePath = rp.FilePath + ".xlsx"
-		}
-
-		match, _ = regexp.MatchString(`xls$`, rp.FilePath)
-		if match {
-			fmt.Printf("Warning: File \"%s\" has extension .xls, should be .xlsx\n", rp.FilePath)
-		}
-	}
-
-	if rp.RepSheet == "" {
-		if len(rp.RepTitle) > 30 {
-			rp.RepSheet = rp.RepTitle[:30]
-		} else {
-			rp.RepSheet = rp.RepTitle
-		}
-	}
-
-	start := time.Now()
-	err = genSheet(file, rp, rptData)
-	if err != nil {
-		fmt.Println("Warning, error generating Excel Sheet:", err.Error())
-	}
-	if Debug {
-		fmt.Println("genSheet() Took:", time.Since(start))
+		rp.FilePath = rp.RepTitle + outputExts[outputFor(rp.Output, "")]
 	}
 
-	err = file.Save(rp.FilePath)
-	if err != nil {
+	file, err := buildReport(ctx, rp, rptData, db)
+	if file == nil {
 		return err
 	}
 
-	return nil
+	return errors.Join(err, saveOutput(file, rp.FilePath, rp, rp.logger()))
 }
 
 // ExcelMultiSheet generates a Report with Multiple Sheets using a datamap that should be loaded by your implementation of LoadRows() function.
+// Sheets that fail are reported as a *SheetError in the returned error.
 func ExcelMultiSheet(filePath string, reports []MultiSheetRep) error {
+	return ExcelMultiSheetContext(context.Background(), filePath, reports)
+}
+
+// ExcelMultiSheetContext is like ExcelMultiSheet but uses ctx for the queries and report generation.
+// If ctx is done before the report is completed, ctx.Err() is returned and no file is written.
+func ExcelMultiSheetContext(ctx context.Context, filePath string, reports []MultiSheetRep) error {
 
 	if filePath == "" {
 		return errors.New("filePath is empty string")
 	}
 
-	var file *xlsx.File
-
-	file = xlsx.NewFile()
-
-	for _, k := range reports {
-		rows, err := k.DB.Query(k.Params.Query)
-		if err != nil {
-			return err
-		}
+	file, err := buildMultiSheet(ctx, reports)
+	if file == nil {
+		return err
+	}
 
-		k.Data.LoadRows(rows)
-		rows.Close()
+	return errors.Join(err, saveOutput(file, filePath, reportsParams(reports), reportsLogger(reports)))
+}
 
-		if k.Params.RepSheet == "" {
-			if len(k.Params.RepTitle) > 30 {
-				k.Params.RepSheet = k.Params.RepTitle[:30]
-			} else {
-				k.Params.RepSheet = k.Params.RepTitle
-			}
-		}
+/*
+ExcelFromDB can be used when the selected columns are not known.
+Uses reflect to infer data type directly from DB.
+*/
+func ExcelFromDB(rp RepParams, db *sql.DB) error {
+	return ExcelFromDBContext(context.Background(), rp, db)
+}
 
-		err = genSheet(file, k.Params, k.Data)
-		if err != nil {
-			fmt.Println("Warning, error generating Excel Sheet:", err.Error())
-		}
+// ExcelFromDBContext is like ExcelFromDB but uses ctx for the query and report generation.
+// If ctx is done before the report is completed, ctx.Err() is returned and no file is written.
+func ExcelFromDBContext(ctx context.Context, rp RepParams, db *sql.DB) error {
+	if rp.FilePath == "" {
+		rp.FilePath = rp.RepTitle + outputExts[outputFor(rp.Output, "")]
 	}
 
-	match, _ := regexp.MatchString(`(?m)([a-zA-Z0-9\s_\\.\-\(\):])+(.xls|.xlsx)$`, filePath)
-	if !match {
-		filePath = filePath + ".xlsx"
+	file, err := buildFromDB(ctx, rp, db)
+	if file == nil {
+		return err
 	}
 
-	match, _ = regexp.MatchString(`xls$`, filePath)
-	if match {
-		fmt.Printf("Warning: File \"%s\" has extension .xls, should be .xlsx\n", filePath)
-	}
+	return errors.Join(err, saveOutput(file, rp.FilePath, rp, rp.logger()))
+}
 
-	err := file.Save(filePath)
-	if err != nil {
+// ExcelMultiSheetFromDB generates a Report with Multiple Sheets.
+// Uses reflect to infer data type directly from DB.
+func ExcelMultiSheetFromDB(filePath string, reports []MultiSheetRep) error {
+	return ExcelMultiSheetFromDBContext(context.Background(), filePath, reports)
+}
+
+// ExcelMultiSheetFromDBContext is like ExcelMultiSheetFromDB but uses ctx for the queries and report generation.
+// If ctx is done before the report is completed, ctx.Err() is returned and no file is written.
+func ExcelMultiSheetFromDBContext(ctx context.Context, filePath string, reports []MultiSheetRep) error {
+	file, err := buildMultiSheetFromDB(ctx, reports)
+	if file == nil {
 		return err
 	}
 
-	return nil
+	return errors.Join(err, saveOutput(file, filePath, reportsParams(reports), reportsLogger(reports)))
 }
 
-/*
-ExcelFromDB can be used when the selected columns are not known.
-Uses reflect to infer data type directly from DB.
-*/
-func ExcelFromDB(rp RepParams, db *sql.DB) error {
+// buildReport generates the workbook for ExcelReport and its variants.
+// A nil file is returned when the workbook must not be written (see buildMultiSheet).
+func buildReport(ctx context.Context, rp RepParams, rptData ReportData, db *sql.DB) (*xlsx.File, error) {
+	return buildMultiSheet(ctx, []MultiSheetRep{{Params: rp, Data: rptData, DB: db}})
+}
 
-	driverType := reflect.TypeOf(db.Driver())
-	switch driverType.String() {
-	case "*mysql.MySQLDriver":
-		fmt.Printf("Warning: MySQL Driver is not reflect friendly.\nPlease use ExcelReport() function for MySQL databases.\n")
-	}
+// buildMultiSheet generates the workbook for ExcelMultiSheet and its variants.
+// A nil file is returned when the workbook must not be written (see buildSheets).
+func buildMultiSheet(ctx context.Context, reports []MultiSheetRep) (*xlsx.File, error) {
+	return buildSheets(ctx, reports, loadSheet)
+}
 
+// buildSheets generates a workbook with the sheets of reports, gen adds the sheet of a report to file.
+// Sheets may be generated concurrently (see Options.Concurrency) but are added in the order of reports.
+//
+// Sheet errors are returned joined along with the file, unless ctx is done, a sheet
+// with StrictMode fails or no sheet was added, in which case the file is nil.
+func buildSheets(ctx context.Context, reports []MultiSheetRep, gen sheetGen) (*xlsx.File, error) {
 	var file *xlsx.File
+	var errs []error
 
 	file = xlsx.NewFile()
 
-	if rp.FilePath == "" {
-		rp.FilePath = rp.RepTitle + ".xlsx"
-	} else {
-		match, _ := regexp.MatchString(`(?m)([a-zA-Z0-9\s_\\.\-\(\):])+(.xls|.xlsx)$`, rp.FilePath)
-		if !match {
-			rp.FilePath = rp.FilePath + ".xlsx"
-		}
+	named := make([]MultiSheetRep, len(reports))
+	for i, k := range reports {
+		k.Params.RepSheet = sheetName(k.Params)
+		named[i] = k
+	}
 
-		match, _ = regexp.MatchString(`xls$`, rp.FilePath)
-		if match {
-			fmt.Printf("Warning: File \"%s\" has extension .xls, should be .xlsx\n", rp.FilePath)
-		}
+	// result returns the workbook with the sheets of report i
+	result := func(i int) (*xlsx.File, error) {
+		return file, gen(ctx, file, named[i])
+	}
+	if n := reportsParams(reports).options().Concurrency; n > 1 && len(named) > 1 {
+		cs := genConcurrently(ctx, named, gen, n)
+		defer cs.stop()
+		result = cs.result
+	}
 
+	for i, k := range named {
+		sheets, err := result(i)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if sheets != file {
+			err = errors.Join(err, appendSheets(file, sheets))
+		}
+		if err != nil {
+			err = &SheetError{Sheet: k.Params.RepSheet, Err: err}
+			if k.Params.StrictMode {
+				return nil, err
+			}
+			errs = append(errs, err)
+		}
 	}
 
-	if rp.RepSheet == "" {
-		if len(rp.RepTitle) > 30 {
-			rp.RepSheet = rp.RepTitle[:30]
-		} else {
-			rp.RepSheet = rp.RepTitle
+	// A workbook with no sheets can't be saved
+	if len(file.Sheets) == 0 {
+		if len(errs) == 0 {
+			errs = append(errs, errors.New("no sheets to be written"))
 		}
+		return nil, errors.Join(errs...)
 	}
+	return file, errors.Join(errs...)
+}
 
-	err := genSheetFromDB(file, rp, db)
+// loadSheet runs the query of rep, loads the results using rep.Data and adds the sheet to file.
+func loadSheet(ctx context.Context, file *xlsx.File, rep MultiSheetRep) error {
+	log := rep.Params.logger()
+
+	start := time.Now()
+	rows, err := rep.DB.QueryContext(ctx, rep.Params.Query, rep.Params.QueryArgs...)
 	if err != nil {
-		fmt.Println("Warning, error generating Excel Sheet:", err.Error())
+		return err
 	}
 
-	err = file.Save(rp.FilePath)
+	err = rep.Data.LoadRows(rows)
+	if err == nil {
+		// LoadRows implementations may not check the error that ended the rows
+		err = rows.Err()
+	}
+	rows.Close()
 	if err != nil {
 		return err
 	}
+	log.Debug("Rows loaded", "sheet", rep.Params.RepSheet, "duration", time.Since(start))
 
-	return nil
+	start = time.Now()
+	err = genSheet(ctx, file, rep.Params, rep.Data)
+	log.Debug("genSheet() finished", "sheet", rep.Params.RepSheet, "duration", time.Since(start))
+	return err
 }
 
-// ExcelMultiSheetFromDB generates a Report with Multiple Sheets.
-// Uses reflect to infer data type directly from DB.
-func ExcelMultiSheetFromDB(filePath string, reports []MultiSheetRep) error {
-
-	// Make sure they are sorted
-	sort.Strings(UntouchCols)
-	var file *xlsx.File
+// buildFromDB generates the workbook for ExcelFromDB and its variants.
+// A nil file is returned when the workbook must not be written (see buildMultiSheet).
+func buildFromDB(ctx context.Context, rp RepParams, db *sql.DB) (*xlsx.File, error) {
+	return buildMultiSheetFromDB(ctx, []MultiSheetRep{{Params: rp, DB: db}})
+}
 
-	file = xlsx.NewFile()
+// buildMultiSheetFromDB generates the workbook for ExcelMultiSheetFromDB and its variants.
+// Errors are handled as in buildMultiSheet.
+func buildMultiSheetFromDB(ctx context.Context, reports []MultiSheetRep) (*xlsx.File, error) {
+	return buildSheets(ctx, reports, func(ctx context.Context, file *xlsx.File, rep MultiSheetRep) error {
+		log := rep.Params.logger()
+		log.Info("Adding Sheet", "sheet", rep.Params.RepSheet)
 
-	for _, k := range reports {
-		if k.Params.RepSheet == "" {
-			if len(k.Params.RepTitle) > 30 {
-				k.Params.RepSheet = k.Params.RepTitle[:30]
-			} else {
-				k.Params.RepSheet = k.Params.RepTitle
-			}
-		}
-		if Vervose {
-			fmt.Println("Adding Sheet", k.Params.RepSheet)
-		}
+		start := time.Now()
+		err := genSheetFromDB(ctx, file, rep.Params, rep.DB)
+		log.Debug("genSheetFromDB() finished", "sheet", rep.Params.RepSheet, "duration", time.Since(start))
+		return err
+	})
+}
 
-		// TODO(moisoto): Test other drivers (tested on mssql and oracle)
-		driverType := reflect.TypeOf(k.DB.Driver())
-		switch driverType.String() {
-		case "*mysql.MySQLDriver":
-			fmt.Printf("On Report Sheet \"%s\" - Warning: MySQL Driver is not reflect friendly.\nPlease use ExcelMultiSheet() function for MySQL databases.\n", k.Params.RepSheet)
-		}
-		genSheetFromDB(file, k.Params, k.DB)
+// sheetName returns the sheet name to be used for rp, RepTitle is used when RepSheet is not set.
+func sheetName(rp RepParams) string {
+	if rp.RepSheet != "" {
+		return rp.RepSheet
+	}
+	if len(rp.RepTitle) > 30 {
+		return rp.RepTitle[:30]
 	}
+	return rp.RepTitle
+}
 
+// xlsxPath adds the .xlsx extension to filePath if needed.
+func xlsxPath(filePath string, log Logger) string {
 	match, _ := regexp.MatchString(`(?m)([a-zA-Z0-9\s_\\.\-\(\):])+(.xls|.xlsx)$`, filePath)
 	if !match {
 		filePath = filePath + ".xlsx"
@@ -254,284 +354,273 @@ func ExcelMultiSheetFromDB(filePath string, reports []MultiSheetRep) error {
 
 	match, _ = regexp.MatchString(`xls$`, filePath)
 	if match {
-		fmt.Printf("Warning: File \"%s\" has extension .xls, should be .xlsx\n", filePath)
+		log.Warn("File has extension .xls, should be .xlsx", "file", filePath)
 	}
 
-	err := file.Save(filePath)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return filePath
 }
 
 // genSheet adds the report in a new sheet.
-func genSheet(file *xlsx.File, rp RepParams, dataMap interface{}) error {
+// data can be a map (rows are ordered by key) or a slice (rows are kept in order) of structs.
+func genSheet(ctx context.Context, file *xlsx.File, rp RepParams, data interface{}) error {
 	var sheet *xlsx.Sheet
-	var row *xlsx.Row
-	var rdata = reflect.ValueOf(dataMap)
-	var timeKind = reflect.TypeOf(time.Time{}).Kind()
+	var records []reflect.Value
+	var opts = rp.options()
+	var log = rp.logger()
 
-	if rdata.Kind() != reflect.Map {
-		return errors.New("dataMap is not a map")
+	if sr, ok := data.(sliceRows); ok {
+		data = sr.rowSlice()
 	}
-	sheet, err := file.AddSheet(rp.RepSheet)
+
+	rdata := reflect.ValueOf(data)
+	switch rdata.Kind() {
+	case reflect.Map:
+		var err error
+		records, err = sortedMapValues(rdata)
+		if err != nil {
+			return err
+		}
+	case reflect.Slice:
+		records = make([]reflect.Value, rdata.Len())
+		for i := range records {
+			records[i] = rdata.Index(i)
+		}
+	default:
+		return errors.New("data is not a map or a slice")
+	}
+
+	cols, err := structColumns(rdata.Type().Elem(), rp.RepCols, log)
 	if err != nil {
 		return err
 	}
 
-	startRow := 1
-	if !rp.NoTitleRow {
-		startRow = 4
-		// Add Report Title
-		sheet.AddRow() // Skip a Row
-		cell := sheet.AddRow().AddCell()
-		s := cell.GetStyle()
-		s.Font.Size = 18
-		s.Font.Bold = true
-		s.ApplyFont = true
-		cell.Value = rp.RepTitle
-		row = sheet.AddRow()
-	}
-
-	// Add Column Titles
-	row = sheet.AddRow()
-	for _, k := range rp.RepCols {
-		cell := row.AddCell()
-		s := cell.GetStyle()
-		s.Fill.PatternType = "solid"
-		s.Fill.FgColor = "004472C4"
-		s.Font.Color = "00FFFFFF"
-		s.Font.Bold = true
-		s.ApplyFill = true
-		s.ApplyFont = true
-		cell.Value = k.Title
+	sheet, err = file.AddSheet(rp.RepSheet)
+	if err != nil {
+		return err
 	}
 
-	flag := false
+	startRow := addTitleRows(sheet, rp)
 
-	/*
-		// Add Rows (Ordered Rows, fast)
-		// This code assumes key is int, is incremental and begins at 1
-		// Assures 100% that the report will be generated in the intended order
-		var i int
-		nitems := rdata.Len()
-		for i = 1; i < nitems; i++ {
-			v := rdata.MapIndex(reflect.ValueOf(i))
-			fmt.Printf("Value: %+v \n", v.Interface())
-
-			if rp.AltBg {
-				flag = i%2 == 0
+	if rp.Pivot != nil {
+		pt, err := newPivotTable(cols, *rp.Pivot)
+		if err != nil {
+			return err
+		}
+		for _, v := range records {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
+			pt.add(func(col column) reflect.Value { return v.Field(col.field) })
 		}
+		pt.write(sheet, rp, startRow)
+		log.Info("Sheet added", "sheet", rp.RepSheet, "rows", len(records), "pivotRows", len(pt.rowKeys))
+		return nil
+	}
 
+	addHeaderRow(sheet, cols)
 
-		// Add Rows (Unordered Rows, flexible, faster)
-		// This code is more flexible, works regardless of key used.
-		// Order of map items on go is unspecified, so the excel will have unordered rows
-		iter := rdata.MapRange()
-		for i = 0; iter.Next(); i++ {
-			v := iter.Value()
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
-		}
-	*/
+	groups, err := newGrouper(sheet, cols, rp.GroupBy, startRow, rp.FooterLabel)
+	if err != nil {
+		return err
+	}
 
-	// Add Rows (Ordered Rows, flexible, slower)
-	// This third implementation intends to accomplish map ordering indepent of key type
-	// Implementer of LoadRows can use any column data for map Type, yielding rows ordered by that column
-	tkeys := reflect.TypeOf(dataMap).Key() // Type of the Map Key
-	rkeys := rdata.MapKeys()               // Slice with Map Keys
-	qkeys := len(rkeys)                    // Alternative: rdata.Len()
+	flag := false
+	qkeys := len(records)
 
-	//switch <- Kind of Map Keys
-	switch tkeys.Kind() {
-	case reflect.Int:
-		ordKeys := make([]int, qkeys, qkeys)
-		for i, k := range rkeys {
-			ordKeys[i] = int(k.Int())
+	for i, v := range records {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		sort.Ints(ordKeys)
-		for i, k := range ordKeys {
-			v := rdata.MapIndex(reflect.ValueOf(k))
 
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
+		if rp.AltBg {
+			flag = i%2 == 0
 		}
-	case reflect.Float32:
-		ordKeys := make([]float32, qkeys, qkeys)
-		for i, k := range rkeys {
-			ordKeys[i] = float32(k.Float())
+		row, err := groups.addRow(func(col column) reflect.Value { return v.Field(col.field) })
+		if err != nil {
+			return err
 		}
-		sort.Slice(ordKeys, func(i, j int) bool {
-			return ordKeys[i] < ordKeys[j]
-		})
-		for i, k := range ordKeys {
-			v := rdata.MapIndex(reflect.ValueOf(k))
+		addRow(v.Interface(), row, flag, cols, opts, log)
+	}
+	if err := groups.close(); err != nil {
+		return err
+	}
+	if groups.row >= maxSheetRows { // No room for the footer
+		return fmt.Errorf("%w: %d rows (only ExcelFromDB reports continue on other sheets)", ErrRowLimit, qkeys)
+	}
+	nrows := groups.row - startRow // Data and subtotal rows
 
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
-		}
-	case reflect.Float64:
-		ordKeys := make([]float64, qkeys, qkeys)
-		for i, k := range rkeys {
-			ordKeys[i] = float64(k.Float())
+	setColumns(sheet, cols)
+	if rp.AutoFilter {
+		setAutoFilter(sheet, startRow, len(cols), nrows)
+	}
+	if qkeys != 0 { // If there's Data to be Processed
+		if err := addFooterRow(sheet, cols, startRow, nrows, rp.FooterLabel); err != nil {
+			return err
 		}
-		sort.Float64s(ordKeys)
-		for i, k := range ordKeys {
-			v := rdata.MapIndex(reflect.ValueOf(k))
+	}
+	log.Info("Sheet added", "sheet", rp.RepSheet, "rows", qkeys)
+	return nil
+}
 
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
-		}
-	case reflect.String:
-		ordKeys := make([]string, qkeys, qkeys)
-		for i, k := range rkeys {
-			ordKeys[i] = (k.String())
-		}
-		sort.Strings(ordKeys)
-		for i, k := range ordKeys {
-			v := rdata.MapIndex(reflect.ValueOf(k))
+// sortedMapValues returns the values of the map rdata ordered by key.
+//
+// Order of map items on go is unspecified, this intends to accomplish map ordering independent of key type.
+// Implementer of LoadRows can use any column data for map Type, yielding rows ordered by that column.
+func sortedMapValues(rdata reflect.Value) ([]reflect.Value, error) {
+	var timeKind = reflect.TypeOf(time.Time{}).Kind()
 
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
-		}
+	rkeys := rdata.MapKeys() // Slice with Map Keys
+
+	//switch <- Kind of Map Keys
+	switch rdata.Type().Key().Kind() {
+	case reflect.Int:
+		sort.Slice(rkeys, func(i, j int) bool {
+			return rkeys[i].Int() < rkeys[j].Int()
+		})
+	case reflect.Float32, reflect.Float64:
+		sort.Slice(rkeys, func(i, j int) bool {
+			return rkeys[i].Float() < rkeys[j].Float()
+		})
+	case reflect.String:
+		sort.Slice(rkeys, func(i, j int) bool {
+			return rkeys[i].String() < rkeys[j].String()
+		})
 	case timeKind:
-		ordKeys := make([]time.Time, qkeys, qkeys)
-		for i, k := range rkeys {
-			kTime := k.Interface().(time.Time)
-			ordKeys[i] = time.Time(kTime)
-		}
-		sort.Slice(ordKeys, func(i, j int) bool {
-			return ordKeys[i].Before(ordKeys[j])
+		sort.Slice(rkeys, func(i, j int) bool {
+			return rkeys[i].Interface().(time.Time).Before(rkeys[j].Interface().(time.Time))
 		})
-		for i, k := range ordKeys {
-			v := rdata.MapIndex(reflect.ValueOf(k))
-
-			if rp.AltBg {
-				flag = i%2 == 0
-			}
-			row = sheet.AddRow()
-			addRow(v.Interface(), row, flag)
-		}
 	default:
-		return fmt.Errorf("dataMap key not a valid kind (%+v)", reflect.TypeOf(dataMap).Key().Kind())
+		return nil, fmt.Errorf("dataMap key not a valid kind (%+v)", rdata.Type().Key().Kind())
 	}
 
-	_ = sheet.SetColWidth(0, len(rp.RepCols)-1, 28.0)
-	if rp.AutoFilter {
-		var brCell string
-		c := len(rp.RepCols)
-		for i := 65; c > 26; c -= 26 {
-			brCell = string(rune(i))
-			i++
-		}
-		brCell = brCell + string(rune(64+c)) + strconv.Itoa(qkeys+startRow)
-		tpCell := "A" + strconv.Itoa(startRow)
-		sheet.AutoFilter = &xlsx.AutoFilter{TopLeftCell: tpCell, BottomRightCell: brCell}
+	values := make([]reflect.Value, len(rkeys))
+	for i, k := range rkeys {
+		values[i] = rdata.MapIndex(k)
 	}
-	/*
-		if rp.AutoFilter {
-			brCell := string(64+len(rp.RepCols)) + strconv.Itoa(qkeys+4)
-			sheet.AutoFilter = &xlsx.AutoFilter{TopLeftCell: "A4", BottomRightCell: brCell}
-		}
-	*/
-	if qkeys != 0 { // If there's Data to be Processed
-		row = sheet.AddRow()
-
-		for c, col := range rp.RepCols {
-			colLetter := string(rune(c + 65))
-			cell := row.AddCell()
-			s := cell.GetStyle()
-			s.Fill.PatternType = "solid"
-			s.Fill.FgColor = "00D0CECE"
-			s.ApplyFill = true
-			if col.SumFlag {
-				formula := "=SUBTOTAL(109," + colLetter + strconv.Itoa(startRow+1) + ":" + colLetter + strconv.Itoa(startRow+qkeys) + ")"
-				cell.SetFloatWithFormat(0, "$#,##0.00")
-				cell.SetFormula(formula)
-				s.Font.Bold = true
-				s.Font.Color = "00FF0000"
-				s.Alignment.Horizontal = "left"
-				s.ApplyAlignment = true
-				s.ApplyFont = true
-			}
-		}
-	}
-	return nil
+	return values, nil
 }
 
-func genSheetFromDB(file *xlsx.File, rp RepParams, db *sql.DB) error {
-	var sheet *xlsx.Sheet
-	var row *xlsx.Row
+// genSheetFromDB adds the report of the query rp.Query in a new sheet
+// (or several of them, see RepParams.Overflow).
+func genSheetFromDB(ctx context.Context, file *xlsx.File, rp RepParams, db *sql.DB) error {
+	return fillSheetFromDB(ctx, fileSheets{file}, rp, db)
+}
 
-	sheet, err := file.AddSheet(rp.RepSheet)
+// fillSheetFromDB adds the report of the query rp.Query to the sheets added by sink.
+func fillSheetFromDB(ctx context.Context, sink sheetSink, rp RepParams, db *sql.DB) error {
+	var opts = rp.options()
+	var log = rp.logger()
+
+	sheet, err := sink.newSheet(rp.RepSheet)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, rp.Query, rp.QueryArgs...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+	log.Debug("Query executed", "sheet", rp.RepSheet, "duration", time.Since(start))
 
-	rows, err := db.Query(rp.Query)
+	names, err := rows.Columns()
 	if err != nil {
 		return err
 	}
 
-	cols, err := rows.Columns()
+	// Cell format of each column is chosen once using column type metadata and the driver dialect
+	ctypes, err := rows.ColumnTypes()
 	if err != nil {
 		return err
 	}
+	dialect := opts.Dialect
+	if dialect == nil {
+		dialect = dialectFor(db)
+	}
+	formats := make([]string, len(names))
+	for c, name := range names {
+		formats[c] = dialect.ColumnFormat(ctypes[c])
+		if opts.untouchCol(name) {
+			formats[c] = ""
+		}
+	}
+
+	// RepCols can override titles, formats and totals of the query columns
+	cols := queryColumns(names, formats, rp.RepCols, log)
+	for c, col := range cols {
+		formats[c] = col.Format
+		log.Debug("Column type", "sheet", rp.RepSheet, "column", names[c], "type", ctypes[c].DatabaseTypeName(), "format", formats[c])
+	}
+
+	// Pivot reports accumulate the rows, the sheet is written after the loop
+	var pt *pivotTable
+	if rp.Pivot != nil {
+		if pt, err = newPivotTable(cols, *rp.Pivot); err != nil {
+			return err
+		}
+	}
+
+	// Grand total of the sheets, when rows overflow to other sheets
+	var grand []*pivotAcc
+	var footerRows = 1
+	if rp.Overflow == OverflowGrandTotal {
+		footerRows++
+		grand = make([]*pivotAcc, len(cols))
+		for c, col := range cols {
+			grand[c] = newPivotAcc(col.aggregate())
+		}
+	}
+
+	var startRow, sheetRows, sheets int
+	var groups *grouper
+	startSheet := func() error {
+		sheets++
+		if sheets > 1 {
+			if sheet, err = sink.newSheet(overflowSheetName(rp.RepSheet, sheets)); err != nil {
+				return err
+			}
+		}
+		startRow = addTitleRows(sheet, rp)
+		if pt != nil {
+			return nil
+		}
+		addHeaderRow(sheet, cols)
+		setColumns(sheet, cols)
+		sheetRows = 0
+		groups, err = newGrouper(sheet, cols, rp.GroupBy, startRow, rp.FooterLabel)
+		return err
+	}
+	endSheet := func() error {
+		if err := groups.close(); err != nil {
+			return err
+		}
+		nrows := groups.row - startRow // Data and subtotal rows
+
+		if rp.AutoFilter {
+			setAutoFilter(sheet, startRow, len(cols), nrows)
+		}
+		if sheetRows != 0 { // If there's Data to be Processed
+			return addFooterRow(sheet, cols, startRow, nrows, rp.FooterLabel)
+		}
+		return nil
+	}
 
-	startRow := 1
-	if !rp.NoTitleRow {
-		startRow = 4
-		// Add Report Title
-		sheet.AddRow() // Skip a Row
-		cell := sheet.AddRow().AddCell()
-		s := cell.GetStyle()
-		s.Font.Size = 18
-		s.Font.Bold = true
-		s.ApplyFont = true
-		cell.Value = rp.RepTitle
-		row = sheet.AddRow()
-	}
-
-	// Add Titles
-	row = sheet.AddRow()
-	for _, k := range cols {
-		cell := row.AddCell()
-		s := cell.GetStyle()
-		s.Fill.PatternType = "solid"
-		s.Fill.FgColor = "004472C4"
-		s.Font.Color = "00FFFFFF"
-		s.Font.Bold = true
-		s.ApplyFill = true
-		s.ApplyFont = true
-		cell.Value = k
+	if err = startSheet(); err != nil {
+		return err
 	}
 
 	var i int
 	flag := false
 	for rows.Next() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
 		// Create a slice of interface{}'s to represent each column,
 		// and a second slice to contain pointers to each item in the columns slice.
-		columns := make([]interface{}, len(cols))
-		columnPointers := make([]interface{}, len(cols))
+		columns := make([]interface{}, len(names))
+		columnPointers := make([]interface{}, len(names))
 		for i := range columns {
 			columnPointers[i] = &columns[i]
 		}
@@ -543,69 +632,79 @@ func genSheetFromDB(file *xlsx.File, rp RepParams, db *sql.DB) error {
 		// Create our map, and retrieve the value for each column from the pointers slice,
 		// storing it in the map with the name of the column as the key.
 		m := make(map[string]interface{})
-		for i, colName := range cols {
+		for i, colName := range names {
 			val := columnPointers[i].(*interface{})
-			m[colName] = *val
+			m[colName] = dialect.Value(*val, ctypes[i])
+		}
+
+		value := func(col column) reflect.Value { return reflect.ValueOf(m[names[col.field]]) }
+		if pt != nil {
+			pt.add(value)
+			i++
+			continue
+		}
+
+		// Room is left for the row, the subtotals that may be added before and after it and the footer
+		if groups.row+2*len(groups.by)+1+footerRows > maxSheetRows {
+			if rp.Overflow == OverflowError {
+				return fmt.Errorf("%w: more than %d rows", ErrRowLimit, i)
+			}
+			if err = endSheet(); err != nil {
+				return err
+			}
+			if err = startSheet(); err != nil {
+				return err
+			}
+			log.Info("Rows continue on a new sheet", "sheet", rp.RepSheet, "rows", i, "sheets", sheets)
 		}
 
 		if rp.AltBg {
 			flag = i%2 == 0
 		}
-		row = sheet.AddRow()
-		addMapRow(cols, m, row, flag) // v.Interface(), row, flag)
+		row, err := groups.addRow(value)
+		if err != nil {
+			return err
+		}
+		addMapRow(names, formats, m, row, flag, opts, log)
+		for c, acc := range grand {
+			acc.add(value(cols[c]))
+		}
 		i++
-		//fmt.Println("Processing Line:", i)
-	}
+		sheetRows++
 
-	//fmt.Println("Report Lines Quantity:", i)
-	_ = sheet.SetColWidth(0, len(cols)-1, 28.0)
-	if rp.AutoFilter {
-		var brCell string
-		c := len(cols)
-		for i = 65; c > 26; c -= 26 {
-			brCell = string(rune(i))
-			i++
-		}
-		brCell = brCell + string(rune(64+c)) + strconv.Itoa(i+startRow)
-		tpCell := "A" + strconv.Itoa(startRow)
-		sheet.AutoFilter = &xlsx.AutoFilter{TopLeftCell: tpCell, BottomRightCell: brCell}
-	}
-
-	if i != 0 { // If there's Data to be Processed
-		row = sheet.AddRow()
-
-		for c := 0; c < len(cols); c++ {
-			//for c, col := range cols {
-			//colLetter := string(c + 65)
-			cell := row.AddCell()
-			s := cell.GetStyle()
-			s.Fill.PatternType = "solid"
-			s.Fill.FgColor = "00D0CECE"
-			s.ApplyFill = true
-			/*
-				if col.SumFlag {
-					formula := "SUM(" + colLetter + "2:" + colLetter + strconv.Itoa(i+1) + ")"
-					cell.SetFloatWithFormat(0, "$#,##0.00")
-					cell.SetFormula(formula)
-					s.Font.Bold = true
-					s.Font.Color = "00FF0000"
-					s.Alignment.Horizontal = "left"
-					s.ApplyAlignment = true
-					s.ApplyFont = true
-				}
-			*/
+		if err = sink.flush(); err != nil {
+			return err
 		}
 	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	// Rows may end early if the driver fails
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if pt != nil {
+		pt.write(sheet, rp, startRow)
+		log.Info("Sheet added", "sheet", rp.RepSheet, "rows", i, "pivotRows", len(pt.rowKeys))
+		return nil
+	}
+	if err = endSheet(); err != nil {
+		return err
+	}
+	if sheets > 1 && grand != nil {
+		addGrandTotalRow(sheet, cols, grand)
+	}
 
+	log.Info("Sheet added", "sheet", rp.RepSheet, "rows", i, "sheets", sheets)
 	return nil
 }
 
-func runningtime(s string) (string, time.Time) {
-	log.Println("Start:	", s)
-	return s, time.Now()
-}
-
-func track(s string, startTime time.Time) {
-	endTime := time.Now()
-	log.Println("End:	", s, "took", endTime.Sub(startTime))
+// overflowSheetName returns the name of the sheet number n of a report with rows on several sheets
+// (i.e. "Sales (2)"), the sheet name is shortened if needed.
+func overflowSheetName(name string, n int) string {
+	suffix := fmt.Sprintf(" (%d)", n)
+	if len(name)+len(suffix) > 31 {
+		name = name[:31-len(suffix)]
+	}
+	return name + suffix
 }
@@ -0,0 +1,277 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nameTestRow struct{ Name CellStr }
+
+// reportFuncs runs a report of rp with each of the entry points that save a file.
+var reportFuncs = map[string]func(ctx context.Context, rp RepParams, db *sql.DB) error{
+	"ExcelReportContext": func(ctx context.Context, rp RepParams, db *sql.DB) error {
+		return ExcelReportContext(ctx, rp, &SliceReport[nameTestRow]{}, db)
+	},
+	"ExcelMultiSheetContext": func(ctx context.Context, rp RepParams, db *sql.DB) error {
+		return ExcelMultiSheetContext(ctx, rp.FilePath, []MultiSheetRep{{Params: rp, Data: &SliceReport[nameTestRow]{}, DB: db}})
+	},
+	"ExcelFromDBContext": func(ctx context.Context, rp RepParams, db *sql.DB) error {
+		return ExcelFromDBContext(ctx, rp, db)
+	},
+	"ExcelMultiSheetFromDBContext": func(ctx context.Context, rp RepParams, db *sql.DB) error {
+		return ExcelMultiSheetFromDBContext(ctx, rp.FilePath, []MultiSheetRep{{Params: rp, DB: db}})
+	},
+}
+
+// nameTestDB returns a query result with a Name column and n rows.
+func nameTestDB(n int) fakeResult {
+	res := fakeResult{cols: []string{"Name"}}
+	for i := 0; i < n; i++ {
+		res.rows = append(res.rows, []driver.Value{"Name " + string(rune('A'+i))})
+	}
+	return res
+}
+
+func TestReportCancel(t *testing.T) {
+	for name, report := range reportFuncs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cancel.xlsx")
+			rp := RepParams{RepTitle: "Cancel", FilePath: path, Logger: discardLogger{}}
+
+			// During the query
+			slow := nameTestDB(5)
+			slow.delay = time.Minute
+			rp.Query = "cancel query " + name
+			db := fakeDB(map[string]fakeResult{rp.Query: slow})
+			defer db.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if err := report(ctx, rp, db); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("canceled query: err = %v, want context.DeadlineExceeded", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("canceled query: file was written")
+			}
+
+			// While reading the rows
+			ctx, cancel = context.WithCancel(context.Background())
+			defer cancel()
+			rows := nameTestDB(5)
+			rows.next = func(pos int) {
+				if pos == 2 {
+					cancel()
+				}
+			}
+			rp.Query = "cancel rows " + name
+			db = fakeDB(map[string]fakeResult{rp.Query: rows})
+			defer db.Close()
+
+			if err := report(ctx, rp, db); !errors.Is(err, context.Canceled) {
+				t.Errorf("canceled rows: err = %v, want context.Canceled", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("canceled rows: file was written")
+			}
+		})
+	}
+}
+
+func TestQueryArgs(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"args first": nameTestDB(1), "args second": nameTestDB(1)})
+	defer db.Close()
+
+	// checkArgs checks the arguments received by the driver for query
+	checkArgs := func(t *testing.T, query string, want ...driver.NamedValue) {
+		t.Helper()
+		got := fakeQueryArgs(query)
+		if len(got) != len(want) {
+			t.Fatalf("%s: driver got %d arguments %v, want %v", query, len(got), got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: argument %d = %+v, want %+v", query, i+1, got[i], want[i])
+			}
+		}
+	}
+
+	for name, report := range reportFuncs {
+		t.Run(name, func(t *testing.T) {
+			rp := RepParams{
+				RepTitle:  "Args",
+				Query:     "args first",
+				QueryArgs: []interface{}{1, "VIP", sql.Named("balance", 10000.5)},
+				FilePath:  filepath.Join(t.TempDir(), "args.xlsx"),
+				Logger:    discardLogger{}}
+			if err := report(context.Background(), rp, db); err != nil {
+				t.Fatal(err)
+			}
+			checkArgs(t, "args first",
+				driver.NamedValue{Ordinal: 1, Value: int64(1)},
+				driver.NamedValue{Ordinal: 2, Value: "VIP"},
+				driver.NamedValue{Name: "balance", Ordinal: 3, Value: 10000.5})
+		})
+	}
+
+	// Each report of a multiple sheets workbook uses its own arguments
+	for name, build := range map[string]func(ctx context.Context, reports []MultiSheetRep) error{
+		"WriteExcelMultiSheet": func(ctx context.Context, reports []MultiSheetRep) error {
+			return WriteExcelMultiSheet(ctx, io.Discard, reports)
+		},
+		"WriteExcelMultiSheetFromDB": func(ctx context.Context, reports []MultiSheetRep) error {
+			return WriteExcelMultiSheetFromDB(ctx, io.Discard, reports)
+		},
+		"WriteExcelMultiSheetFromDBStream": func(ctx context.Context, reports []MultiSheetRep) error {
+			return WriteExcelMultiSheetFromDBStream(ctx, io.Discard, reports)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reports := []MultiSheetRep{
+				{Params: RepParams{RepSheet: "First", Query: "args first", QueryArgs: []interface{}{"a"}, Logger: discardLogger{}}, Data: &SliceReport[nameTestRow]{}, DB: db},
+				{Params: RepParams{RepSheet: "Second", Query: "args second", QueryArgs: []interface{}{"b", 2}, Logger: discardLogger{}}, Data: &SliceReport[nameTestRow]{}, DB: db},
+			}
+			if err := build(context.Background(), reports); err != nil {
+				t.Fatal(err)
+			}
+			checkArgs(t, "args first", driver.NamedValue{Ordinal: 1, Value: "a"})
+			checkArgs(t, "args second", driver.NamedValue{Ordinal: 1, Value: "b"}, driver.NamedValue{Ordinal: 2, Value: int64(2)})
+		})
+	}
+}
+
+// logEntry is a message received by captureLogger.
+type logEntry struct {
+	level, msg string
+	fields     map[string]interface{}
+}
+
+// captureLogger is a Logger that keeps the messages it receives.
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *captureLogger) add(level, msg string, keysAndValues []interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, logEntry{level, msg, fields})
+	l.mu.Unlock()
+}
+
+func (l *captureLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.add("debug", msg, keysAndValues)
+}
+func (l *captureLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.add("info", msg, keysAndValues)
+}
+func (l *captureLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.add("warn", msg, keysAndValues)
+}
+
+// entry returns the fields of the first message msg, nil if it was not received.
+func (l *captureLogger) entry(msg string) map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		if e.msg == msg {
+			return e.fields
+		}
+	}
+	return nil
+}
+
+func TestLoggerFields(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"logger": nameTestDB(3)})
+	defer db.Close()
+
+	for name, write := range map[string]func(rp RepParams) error{
+		"WriteExcelReport": func(rp RepParams) error {
+			return WriteExcelReport(context.Background(), io.Discard, rp, &SliceReport[nameTestRow]{}, db)
+		},
+		"WriteExcelFromDB": func(rp RepParams) error {
+			return WriteExcelFromDB(context.Background(), io.Discard, rp, db)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			log := &captureLogger{}
+			if err := write(RepParams{RepSheet: "Logged", Query: "logger", Logger: log}); err != nil {
+				t.Fatal(err)
+			}
+
+			timed := []string{"Rows loaded", "genSheet() finished"}
+			if name == "WriteExcelFromDB" {
+				timed = []string{"Query executed", "genSheetFromDB() finished"}
+			}
+			for _, msg := range timed {
+				fields := log.entry(msg)
+				if fields == nil {
+					t.Errorf("message %q not logged", msg)
+					continue
+				}
+				if fields["sheet"] != "Logged" {
+					t.Errorf("%q: sheet = %v, want Logged", msg, fields["sheet"])
+				}
+				if _, ok := fields["duration"].(time.Duration); !ok {
+					t.Errorf("%q: duration = %v, want a time.Duration", msg, fields["duration"])
+				}
+			}
+
+			fields := log.entry("Sheet added")
+			if fields == nil || fields["sheet"] != "Logged" || fields["rows"] != 3 {
+				t.Errorf(`"Sheet added" fields = %v, want sheet Logged and 3 rows`, fields)
+			}
+		})
+	}
+}
+
+func TestRepParamsOptions(t *testing.T) {
+	oldCols, oldStrings := UntouchCols, UntouchStrings
+	defer func() { UntouchCols, UntouchStrings = oldCols, oldStrings }()
+	UntouchCols, UntouchStrings = []string{"Zip", "Code"}, true
+
+	// Package level variables are the defaults of reports with no Options, there is none for Concurrency
+	opts := RepParams{}.options()
+	if !opts.UntouchStrings || opts.Concurrency != 0 || !opts.untouchCol("Code") || !opts.untouchCol("Zip") {
+		t.Errorf("default options = %+v, want the package level values", opts)
+	}
+	if UntouchCols[0] != "Zip" {
+		t.Errorf("UntouchCols was sorted: %v", UntouchCols)
+	}
+
+	// Options of the call are used instead, the caller's values are not modified
+	callOpts := &Options{UntouchCols: []string{"Phone", "Account", "Id"}}
+	opts = RepParams{Options: callOpts}.options()
+	if opts.UntouchStrings || opts.Concurrency != 0 {
+		t.Errorf("call options = %+v, package level values were used", opts)
+	}
+	for _, col := range []string{"Phone", "Account", "Id"} {
+		if !opts.untouchCol(col) {
+			t.Errorf("untouchCol(%q) = false", col)
+		}
+	}
+	for _, col := range []string{"Zip", "Code", "phone", ""} {
+		if opts.untouchCol(col) {
+			t.Errorf("untouchCol(%q) = true", col)
+		}
+	}
+	if want := []string{"Phone", "Account", "Id"}; !slices.Equal(callOpts.UntouchCols, want) {
+		t.Errorf("caller's UntouchCols = %v, want %v", callOpts.UntouchCols, want)
+	}
+	opts.UntouchCols[0] = "Changed"
+	if callOpts.UntouchCols[0] != "Phone" {
+		t.Errorf("options share UntouchCols with the caller")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/moisoto/xlsrpt
 
-go 1.13
+go 1.21
 
 require (
 	github.com/tealeg/xlsx v1.0.5
@@ -0,0 +1,141 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+// maxGroupLevels is the number of GroupBy columns allowed, excel supports 7 outline levels
+// and one of them is used for the data rows.
+const maxGroupLevels = 6
+
+// grouper adds the rows of a report grouped by RepParams.GroupBy.
+//
+// Rows must be ordered by the group columns, a group ends when the value of its column
+// (or of an outer group column) changes and its subtotal row is added.
+// Rows get outline levels so groups can be collapsed: the footer row (grand total) is on level 0,
+// subtotals of the outermost group on level 1 and data rows on the deepest level.
+type grouper struct {
+	sheet *xlsx.Sheet
+	cols  []column
+	by    []int    // Column index of each group level
+	keys  []string // Value of the open group of each level
+	first []int    // Row number of the first row of the open group of each level
+	label string   // Added to the group value on subtotal rows
+	row   int      // Row number of the last row added
+}
+
+// newGrouper returns a grouper for the rows following startRow.
+// groupBy items are matched with the column titles or names (struct field or query column), ignoring case.
+func newGrouper(sheet *xlsx.Sheet, cols []column, groupBy []string, startRow int, label string) (*grouper, error) {
+	if len(groupBy) > maxGroupLevels {
+		return nil, fmt.Errorf("GroupBy has %d columns, up to %d are allowed", len(groupBy), maxGroupLevels)
+	}
+
+	// COUNTIF would count the subtotals on the range as values
+	for _, col := range cols {
+		if col.Formula == "" && col.aggregate() == AggCountDistinct {
+			return nil, fmt.Errorf("column %q: aggregate %q can't be used with GroupBy", col.Title, AggCountDistinct)
+		}
+	}
+
+	g := &grouper{sheet: sheet, cols: cols, label: label, row: startRow}
+	for _, name := range groupBy {
+		c := groupColumn(cols, name)
+		if c < 0 {
+			return nil, fmt.Errorf("GroupBy column %q not found", name)
+		}
+		g.by = append(g.by, c)
+	}
+	return g, nil
+}
+
+// groupColumn returns the index of the column matching name, -1 if not found.
+func groupColumn(cols []column, name string) int {
+	for c, col := range cols {
+		if strings.EqualFold(col.Title, name) || strings.EqualFold(col.name, name) {
+			return c
+		}
+	}
+	return -1
+}
+
+// addRow adds a data row, subtotal rows of the groups ended by it are added first.
+// value returns the value of a column on the new row.
+func (g *grouper) addRow(value func(col column) reflect.Value) (*xlsx.Row, error) {
+	if len(g.by) == 0 {
+		g.row++
+		return g.sheet.AddRow(), nil
+	}
+
+	// level is the first group level changed by the row
+	level := len(g.keys)
+	keys := make([]string, len(g.by))
+	for l, c := range g.by {
+		keys[l] = groupKey(value(g.cols[c]))
+		if l < level && keys[l] != g.keys[l] {
+			level = l
+		}
+	}
+
+	if err := g.closeGroups(level); err != nil {
+		return nil, err
+	}
+	for l := level; l < len(g.by); l++ {
+		g.keys = append(g.keys, keys[l])
+		g.first = append(g.first, g.row+1)
+	}
+
+	row := g.sheet.AddRow()
+	row.OutlineLevel = uint8(len(g.by) + 1)
+	g.row++
+	return row, nil
+}
+
+// close adds the subtotal rows of the open groups.
+func (g *grouper) close() error {
+	return g.closeGroups(0)
+}
+
+// closeGroups adds the subtotal rows of the open groups, from the deepest one up to level.
+func (g *grouper) closeGroups(level int) error {
+	for l := len(g.keys) - 1; l >= level; l-- {
+		label := g.keys[l]
+		if g.label != "" {
+			label += " " + g.label
+		}
+		row, err := addTotalRow(g.sheet, g.cols, g.first[l], g.row, g.by[l], label)
+		if err != nil {
+			return err
+		}
+		row.OutlineLevel = uint8(l + 1)
+		g.row++
+	}
+	g.keys = g.keys[:level]
+	g.first = g.first[:level]
+	return nil
+}
+
+// groupKey returns the value of a group column as shown on its subtotal row.
+func groupKey(val reflect.Value) string {
+	var timeType = reflect.TypeOf(time.Time{})
+
+	v, ok := unwrapValue(val)
+	switch {
+	case !ok:
+		return ""
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return string(v.Bytes())
+	case v.Type().ConvertibleTo(timeType) && v.CanInterface():
+		t := v.Convert(timeType).Interface().(time.Time)
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,118 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+type groupTestRow struct {
+	Region CellStr
+	Branch CellStr
+	Amount CellDecimal `xlsrpt:"sum"`
+}
+
+func TestGroupBy(t *testing.T) {
+	file := xlsx.NewFile()
+	rp := RepParams{RepSheet: "Groups", NoTitleRow: true, GroupBy: []string{"region", "Branch"}, FooterLabel: "Total", Logger: discardLogger{}}
+	rows := []groupTestRow{
+		{"North", "A", 1}, {"North", "A", 2}, {"North", "B", 3},
+		{"South", "A", 4}}
+	if err := genSheet(context.Background(), file, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows: header, 2 data, A subtotal, 1 data, B subtotal, North subtotal,
+	// 1 data, A subtotal, South subtotal, footer
+	want := []struct {
+		level   uint8
+		label   string
+		labelC  int
+		formula string
+	}{
+		{0, "Region", 0, ""},
+		{3, "North", 0, ""},
+		{3, "North", 0, ""},
+		{2, "A Total", 1, "=SUBTOTAL(109,C2:C3)"},
+		{3, "North", 0, ""},
+		{2, "B Total", 1, "=SUBTOTAL(109,C5:C5)"},
+		{1, "North Total", 0, "=SUBTOTAL(109,C2:C6)"},
+		{3, "South", 0, ""},
+		{2, "A Total", 1, "=SUBTOTAL(109,C8:C8)"},
+		{1, "South Total", 0, "=SUBTOTAL(109,C8:C9)"},
+		{0, "Total", 0, "=SUBTOTAL(109,C2:C10)"},
+	}
+
+	sheet := file.Sheets[0]
+	if len(sheet.Rows) != len(want) {
+		t.Fatalf("sheet has %d rows, want %d", len(sheet.Rows), len(want))
+	}
+	for r, w := range want {
+		row := sheet.Rows[r]
+		if row.OutlineLevel != w.level {
+			t.Errorf("row %d: outline level = %d, want %d", r+1, row.OutlineLevel, w.level)
+		}
+		if got := row.Cells[w.labelC].Value; got != w.label {
+			t.Errorf("row %d: label = %q, want %q", r+1, got, w.label)
+		}
+		if got := row.Cells[2].Formula(); got != w.formula {
+			t.Errorf("row %d: formula = %q, want %q", r+1, got, w.formula)
+		}
+	}
+}
+
+func TestGroupByFromDB(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"groups": {
+			cols:  []string{"region", "amount"},
+			types: []string{"VARCHAR", "INT"},
+			rows: [][]driver.Value{
+				{[]byte("North"), "1"}, {[]byte("North"), "2"}, {[]byte("South"), "3"}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepSheet:   "Groups",
+		Query:      "groups",
+		NoTitleRow: true,
+		RepCols:    []RepColumns{{Title: "Region"}, {Title: "Amount", SumFlag: true}},
+		GroupBy:    []string{"REGION"},
+		Logger:     discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := file.Sheets[0]
+	if len(sheet.Rows) != 7 {
+		t.Fatalf("sheet has %d rows, want 7", len(sheet.Rows))
+	}
+	if got := sheet.Cell(3, 0).Value; got != "North" {
+		t.Errorf("subtotal label = %q, want %q", got, "North")
+	}
+	if got := sheet.Cell(3, 1).Formula(); got != "=SUBTOTAL(109,B2:B3)" {
+		t.Errorf("North subtotal = %q", got)
+	}
+	if got := sheet.Cell(6, 1).Formula(); got != "=SUBTOTAL(109,B2:B6)" {
+		t.Errorf("grand total = %q", got)
+	}
+	if sheet.Rows[1].OutlineLevel != 2 || sheet.Rows[3].OutlineLevel != 1 || sheet.Rows[6].OutlineLevel != 0 {
+		t.Errorf("outline levels = %d, %d, %d", sheet.Rows[1].OutlineLevel, sheet.Rows[3].OutlineLevel, sheet.Rows[6].OutlineLevel)
+	}
+}
+
+func TestGroupByUnknownColumn(t *testing.T) {
+	rp := RepParams{RepSheet: "Groups", GroupBy: []string{"Country"}, Logger: discardLogger{}}
+	if err := genSheet(context.Background(), xlsx.NewFile(), rp, []groupTestRow{}); err == nil {
+		t.Error("unknown GroupBy column returned no error")
+	}
+}
+
+func TestGroupByCountDistinct(t *testing.T) {
+	rp := RepParams{RepSheet: "Groups", GroupBy: []string{"Region"}, Logger: discardLogger{},
+		RepCols: []RepColumns{{}, {Title: "Branch", Aggregate: AggCountDistinct}}}
+	if err := genSheet(context.Background(), xlsx.NewFile(), rp, []groupTestRow{{"North", "A", 1}}); err == nil {
+		t.Error("AggCountDistinct with GroupBy returned no error")
+	}
+}
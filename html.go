@@ -0,0 +1,152 @@
+package xlsrpt
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+HTMLOptions - Options for reports written as HTML (see OutputHTML), set on RepParams.HTML.
+
+Each sheet is written as a table that mirrors it: the report title, the column titles with the header
+colors, AltBg banding, subtotal and footer rows, and values formatted as shown by excel (i.e. $1,234.50).
+Totals are computed since formulas can't run in HTML, see RepColumns.Formula for the supported formulas.
+Styles are set inline so tables can be embedded in emails. Hidden columns are not written.
+
+Fragment writes only the tables, with no HTML document around them. Decimal is the decimal separator
+('.' when not set, thousands are separated by '.' when it is ','), and DateFormat the time layout for dates
+(2006-01-02, adding the time for values that have one, when not set).
+*/
+type HTMLOptions struct {
+	Fragment   bool
+	Decimal    rune
+	DateFormat string
+}
+
+// writeHTML writes the workbook sheets to w as HTML tables.
+func writeHTML(w io.Writer, file *xlsx.File, opts *HTMLOptions) error {
+	if opts == nil {
+		opts = &HTMLOptions{}
+	}
+	tf := textFormat{decimal: opts.Decimal, dateFormat: opts.DateFormat, display: true}
+	bw := bufio.NewWriter(w)
+
+	if !opts.Fragment {
+		title := ""
+		if len(file.Sheets) > 0 {
+			if title = sheetTitle(file.Sheets[0]); title == "" {
+				title = file.Sheets[0].Name
+			}
+		}
+		fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	}
+	for _, sheet := range file.Sheets {
+		writeHTMLTable(bw, sheet, tf)
+	}
+	if !opts.Fragment {
+		bw.WriteString("</body>\n</html>\n")
+	}
+	return bw.Flush()
+}
+
+// writeHTMLTable writes sheet as an HTML table.
+func writeHTMLTable(w *bufio.Writer, sheet *xlsx.Sheet, tf textFormat) {
+	w.WriteString(`<table style="border-collapse:collapse;font-family:Calibri,Arial,sans-serif;font-size:11pt;margin-bottom:1em">` + "\n")
+	if title := sheetTitle(sheet); title != "" {
+		fmt.Fprintf(w, "<caption style=\"font-size:18pt;font-weight:bold;text-align:left;padding:0.5em 0\">%s</caption>\n", html.EscapeString(title))
+	}
+
+	section := ""
+	for r, kind := range sheetRowKinds(sheet) {
+		var tag, next string
+		switch kind {
+		case titleRow:
+			continue
+		case headerRow:
+			tag, next = "th", "thead"
+		case footerRow, grandTotalRow:
+			tag, next = "td", "tfoot"
+		default:
+			tag, next = "td", "tbody"
+		}
+		if next != section {
+			if section != "" {
+				fmt.Fprintf(w, "</%s>\n", section)
+			}
+			fmt.Fprintf(w, "<%s>\n", next)
+			section = next
+		}
+
+		w.WriteString("<tr>")
+		for c, cell := range sheet.Rows[r].Cells {
+			if c < len(sheet.Cols) && sheet.Cols[c] != nil && sheet.Cols[c].Hidden {
+				continue
+			}
+			fmt.Fprintf(w, "<%s style=\"%s\">%s</%s>", tag, cellCSS(cell), html.EscapeString(htmlCellText(sheet, cell, tf)), tag)
+		}
+		w.WriteString("</tr>\n")
+	}
+	if section != "" {
+		fmt.Fprintf(w, "</%s>\n", section)
+	}
+	w.WriteString("</table>\n")
+}
+
+// htmlCellText returns the text of a cell, formulas are replaced by their value (see evalFormula).
+func htmlCellText(sheet *xlsx.Sheet, cell *xlsx.Cell, tf textFormat) string {
+	if cell.Formula() == "" {
+		return tf.cellText(cell)
+	}
+	v, ok := evalFormula(sheet, cell.Formula())
+	if !ok {
+		return ""
+	}
+	return tf.numberText(v, cell.GetNumberFormat())
+}
+
+// sheetTitle returns the report title of sheet, empty if it has no title rows.
+func sheetTitle(sheet *xlsx.Sheet) string {
+	for r, kind := range sheetRowKinds(sheet) {
+		if kind != titleRow {
+			break
+		}
+		if cells := sheet.Rows[r].Cells; len(cells) > 0 && cells[0].Value != "" {
+			return cells[0].Value
+		}
+	}
+	return ""
+}
+
+// cellCSS returns the inline style of a cell, as set by the cell style.
+func cellCSS(cell *xlsx.Cell) string {
+	s := cell.GetStyle()
+	css := []string{"padding:2px 6px", "border:1px solid #D9D9D9"}
+	if s.ApplyFill && s.Fill.PatternType == "solid" {
+		css = append(css, "background-color:"+cssColor(s.Fill.FgColor))
+	}
+	if s.ApplyFont {
+		if s.Font.Bold {
+			css = append(css, "font-weight:bold")
+		}
+		if s.Font.Color != "" {
+			css = append(css, "color:"+cssColor(s.Font.Color))
+		}
+	}
+	if align := s.Alignment.Horizontal; align != "" && align != "general" {
+		css = append(css, "text-align:"+align)
+	}
+	return strings.Join(css, ";")
+}
+
+// cssColor returns an excel ARGB color (i.e. 004472C4) as a CSS color (i.e. #4472C4).
+func cssColor(argb string) string {
+	if len(argb) == 8 {
+		argb = argb[2:]
+	}
+	return "#" + argb
+}
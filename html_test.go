@@ -0,0 +1,81 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	rp := RepParams{
+		RepTitle: "Sales <Q1>",
+		RepCols: []RepColumns{
+			{Title: "Name"}, {Title: "Count", Aggregate: AggCount}, {Title: "Rate", Hidden: true},
+			{Title: "Share", Format: FormatPercent}, {Title: "Balance", SumFlag: true}, {Title: "Since"}},
+		AltBg:       true,
+		FooterLabel: "Total",
+		Output:      OutputHTML}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, csvTestRows()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Sales &lt;Q1&gt;</title>",
+		">Sales &lt;Q1&gt;</caption>",
+		"background-color:#4472C4;font-weight:bold;color:#FFFFFF\">Name</th>",
+		"background-color:#B4C6E7;text-align:left\">Smith, John</td>",
+		">12.50%</td>",
+		">$1,500.25</td>",
+		">-$20.00</td>",
+		">2024-01-02 15:04:05</td>",
+		"<tfoot>\n<tr>",
+		"font-weight:bold\">Total</td>",
+		"color:#FF0000;text-align:left\">2</td>",
+		"color:#FF0000;text-align:left\">$1,480.25</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("html has no %q", want)
+		}
+	}
+	// Hidden columns are not written
+	if strings.Contains(got, "Rate") {
+		t.Error("hidden column written")
+	}
+	if strings.Contains(got, `"hi"`) {
+		t.Error("text not escaped")
+	}
+}
+
+func TestWriteHTMLFragment(t *testing.T) {
+	rp := RepParams{
+		RepTitle:   "Fragment",
+		RepCols:    []RepColumns{{Title: "Name"}, {Title: "Count"}, {Title: "Rate"}, {Title: "Share"}, {Title: "Balance"}, {Title: "Since"}},
+		NoTitleRow: true,
+		FilePath:   "fragment.html",
+		HTML:       &HTMLOptions{Fragment: true, Decimal: ','}}
+
+	var buf bytes.Buffer
+	file, err := buildFromSlice(context.Background(), rp, csvTestRows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutput(&buf, file, outputFor(rp.Output, rp.FilePath), rp); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	if !strings.HasPrefix(got, "<table") || !strings.HasSuffix(got, "</table>\n") {
+		t.Errorf("fragment is not a table:\n%s", got)
+	}
+	if strings.Contains(got, "<caption") {
+		t.Error("caption written for a report with no title row")
+	}
+	if !strings.Contains(got, ">$1.500,25</td>") {
+		t.Error("decimal separator not used")
+	}
+}
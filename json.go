@@ -0,0 +1,240 @@
+package xlsrpt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+JSONOptions - Options for reports written as JSON or NDJSON (see OutputJSON), set on RepParams.JSON.
+
+Each data row is written as an object with the column titles as keys, in the order of the columns.
+Values keep the type of their cells: numbers (with the value saved on the cell, not rounded to its format),
+booleans, strings and dates (as 2006-01-02, or 2006-01-02T15:04:05 for values with a time, unless DateFormat
+is set). Empty cells and NaN or infinite numbers are null. Title, subtotal and footer rows are not written as rows, and the rows of all
+the sheets are written one after the other.
+
+JSON output is an array of the rows, unless Metadata is set. In that case it is an object with the report
+title, the generation time (RFC 3339), the query (omitted when empty), the rows and the footer totals (keyed
+by column title, omitted when the report has no footer or its totals are split across several sheets).
+Indent is used to indent JSON output (i.e. "  "), it is written in a single line when empty.
+
+NDJSON output has a line for each row. When Metadata is set, the first line holds the title, generation time
+and query, and a last line holds the totals as {"totals":{...}}.
+*/
+type JSONOptions struct {
+	Metadata   bool
+	Indent     string
+	DateFormat string
+}
+
+// jsonField is a key and value of a jsonObject.
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonObject is a JSON object that keeps the order of its fields.
+type jsonObject []jsonField
+
+// MarshalJSON implements json.Marshaler.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// jsonReport holds the rows and totals of the workbook sheets.
+type jsonReport struct {
+	rows   []jsonObject
+	totals jsonObject
+}
+
+// newJSONReport returns the data rows and the totals of the workbook sheets.
+func newJSONReport(file *xlsx.File, opts *JSONOptions) *jsonReport {
+	rep := &jsonReport{}
+	footers := 0
+	grand := false
+	for _, sheet := range file.Sheets {
+		var keys []string
+		for r, kind := range sheetRowKinds(sheet) {
+			row := sheet.Rows[r]
+			switch kind {
+			case headerRow:
+				keys = rowText(row, textFormat{})
+			case dataRow:
+				rep.rows = append(rep.rows, jsonRow(sheet, row, keys, opts, false))
+			case footerRow:
+				footers++
+				rep.totals = jsonRow(sheet, row, keys, opts, true)
+			case grandTotalRow:
+				// The totals of all the sheets, see OverflowGrandTotal
+				grand = true
+				rep.totals = jsonRow(sheet, row, keys, opts, true)
+			}
+		}
+	}
+	if footers != 1 && !grand {
+		rep.totals = nil
+	}
+	return rep
+}
+
+// jsonRow returns the object for a row, keys are the column titles.
+// When totals is set, only the cells with a value are included (i.e. not the label).
+func jsonRow(sheet *xlsx.Sheet, row *xlsx.Row, keys []string, opts *JSONOptions, totals bool) jsonObject {
+	obj := make(jsonObject, 0, len(keys))
+	for c, key := range keys {
+		var value interface{}
+		if c < len(row.Cells) {
+			value = jsonValue(sheet, row.Cells[c], opts)
+		}
+		if totals {
+			if _, ok := value.(json.Number); !ok {
+				continue
+			}
+		}
+		obj = append(obj, jsonField{key, value})
+	}
+	return obj
+}
+
+// jsonValue returns the value of a cell with the type of the cell, formulas are replaced by their value (see evalFormula).
+func jsonValue(sheet *xlsx.Sheet, cell *xlsx.Cell, opts *JSONOptions) interface{} {
+	if formula := cell.Formula(); formula != "" {
+		if v, ok := evalFormula(sheet, formula); ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return nil
+	}
+	if cell.Value == "" {
+		return nil
+	}
+
+	switch cell.Type() {
+	case xlsx.CellTypeBool:
+		return cell.Bool()
+	case xlsx.CellTypeNumeric:
+		if cell.IsTime() {
+			t, err := cell.GetTime(false)
+			if err != nil {
+				break
+			}
+			if opts.DateFormat != "" {
+				return t.Format(opts.DateFormat)
+			}
+			// Excel times are rounded to the millisecond
+			t = t.Round(time.Millisecond)
+			if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+				return t.Format("2006-01-02")
+			}
+			return t.Format("2006-01-02T15:04:05")
+		}
+		if f, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				// Not valid JSON numbers
+				return nil
+			}
+			return json.Number(cell.Value)
+		}
+	}
+	return cell.Value
+}
+
+// writeJSON writes the data rows of the workbook sheets to w as JSON, see JSONOptions.
+func writeJSON(w io.Writer, file *xlsx.File, rp RepParams) error {
+	opts := rp.JSON
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+	rep := newJSONReport(file, opts)
+
+	var out interface{} = rep.rows
+	if rep.rows == nil {
+		out = []jsonObject{}
+	}
+	if opts.Metadata {
+		meta := jsonMetadata(rp)
+		meta = append(meta, jsonField{"rows", out})
+		if rep.totals != nil {
+			meta = append(meta, jsonField{"totals", rep.totals})
+		}
+		out = meta
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	if opts.Indent != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, b, "", opts.Indent); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+// writeNDJSON writes the data rows of the workbook sheets to w as NDJSON, see JSONOptions.
+func writeNDJSON(w io.Writer, file *xlsx.File, rp RepParams) error {
+	opts := rp.JSON
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+	rep := newJSONReport(file, opts)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if opts.Metadata {
+		if err := enc.Encode(jsonMetadata(rp)); err != nil {
+			return err
+		}
+	}
+	for _, row := range rep.rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	if opts.Metadata && rep.totals != nil {
+		if err := enc.Encode(jsonObject{{"totals", rep.totals}}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// jsonMetadata returns the metadata fields of the report.
+func jsonMetadata(rp RepParams) jsonObject {
+	meta := jsonObject{{"title", rp.RepTitle}, {"generated", time.Now().Format(time.RFC3339)}}
+	if rp.Query != "" {
+		meta = append(meta, jsonField{"query", rp.Query})
+	}
+	return meta
+}
@@ -0,0 +1,163 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestWriteJSON(t *testing.T) {
+	rp := RepParams{
+		RepTitle: "Sales",
+		RepCols: []RepColumns{
+			{Title: "Name"}, {Title: "Count", Aggregate: AggCount}, {Title: "Rate"},
+			{Title: "Share", Format: FormatPercent}, {Title: "Balance", SumFlag: true}, {Title: "Since"}},
+		FooterLabel: "Total",
+		Output:      OutputJSON}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, csvTestRows()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[{"Name":"Smith, John","Count":3,"Rate":1234.5,"Share":0.125,"Balance":1500.25,"Since":"2023-05-01"},` +
+		`{"Name":"Say \"hi\"","Count":-2,"Rate":-0.4,"Share":1,"Balance":-20,"Since":"2024-01-02T15:04:05"}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("json =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteJSONNumbers(t *testing.T) {
+	type numberRow struct {
+		Price CellCurrency
+		Ratio float64
+	}
+	rows := []numberRow{{19.99, math.NaN()}, {0.1, math.Inf(1)}, {2.5, math.Inf(-1)}}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, RepParams{RepTitle: "Numbers", Output: OutputJSON}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// CellCurrency values have the digits of float32, non finite numbers are not valid JSON
+	want := `[{"Price":19.99,"Ratio":null},{"Price":0.1,"Ratio":null},{"Price":2.5,"Ratio":null}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+}
+
+func TestWriteJSONMetadata(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"jsonmeta": overflowTestDB(3)})
+	defer db.Close()
+
+	rp := RepParams{
+		RepTitle:    "Amounts",
+		Query:       "jsonmeta",
+		RepCols:     []RepColumns{{Title: "Name"}, {Title: "Amount", Aggregate: AggAverage, Format: FormatDecimal}},
+		FooterLabel: "Total",
+		Output:      OutputJSON,
+		JSON:        &JSONOptions{Metadata: true, Indent: "  "},
+		Logger:      discardLogger{}}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromDB(context.Background(), &buf, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\n  \"rows\": [\n") {
+		t.Errorf("json not indented:\n%s", buf.String())
+	}
+
+	var got struct {
+		Title     string
+		Generated time.Time
+		Query     string
+		Rows      []map[string]interface{}
+		Totals    map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Amounts" || got.Query != "jsonmeta" || got.Generated.IsZero() {
+		t.Errorf("metadata = %q, %q, %v", got.Title, got.Query, got.Generated)
+	}
+	if len(got.Rows) != 3 || got.Rows[2]["Name"] != "Row 3" || got.Rows[2]["Amount"] != 3.0 {
+		t.Errorf("rows = %v", got.Rows)
+	}
+	if len(got.Totals) != 1 || got.Totals["Amount"] != 2.0 {
+		t.Errorf("totals = %v, want Amount: 2", got.Totals)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	setMaxSheetRows(t, 10)
+	db := fakeDB(map[string]fakeResult{"ndjson": overflowTestDB(12)})
+	defer db.Close()
+
+	rp := RepParams{
+		RepTitle: "Amounts",
+		Query:    "ndjson",
+		RepCols:  []RepColumns{{Title: "Name"}, {Title: "Amount", SumFlag: true}},
+		Overflow: OverflowGrandTotal,
+		FilePath: "amounts.ndjson",
+		JSON:     &JSONOptions{Metadata: true},
+		Logger:   discardLogger{}}
+
+	file, err := buildFromDB(context.Background(), rp, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Sheets) < 2 {
+		t.Fatalf("report has %d sheets, want several", len(file.Sheets))
+	}
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, file, outputFor(rp.Output, rp.FilePath), rp); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("ndjson has %d lines, want 14:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], `{"title":"Amounts","generated":"`) || !strings.HasSuffix(lines[0], `","query":"ndjson"}`) {
+		t.Errorf("metadata line = %s", lines[0])
+	}
+	if lines[12] != `{"Name":"Row 12","Amount":12}` {
+		t.Errorf("last row = %s", lines[12])
+	}
+	// Totals of all the sheets, from the grand total row
+	if lines[13] != `{"totals":{"Amount":78}}` {
+		t.Errorf("totals line = %s", lines[13])
+	}
+}
+
+func TestJSONTotalsSplit(t *testing.T) {
+	setMaxSheetRows(t, 10)
+	file := xlsx.NewFile()
+	db := fakeDB(map[string]fakeResult{"jsonsplit": overflowTestDB(12)})
+	defer db.Close()
+
+	rp := RepParams{RepSheet: "Split", Query: "jsonsplit", RepCols: []RepColumns{{Title: "Name"}, {Title: "Amount", SumFlag: true}}, Logger: discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	// Each sheet has its own footer, there are no totals for the whole report
+	if rep := newJSONReport(file, &JSONOptions{}); len(rep.rows) != 12 || rep.totals != nil {
+		t.Errorf("%d rows, totals %v", len(rep.rows), rep.totals)
+	}
+
+	// Footers labeled as the grand total row are still the totals of each sheet
+	file = xlsx.NewFile()
+	rp.FooterLabel = grandTotalLabel
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	if rep := newJSONReport(file, &JSONOptions{}); rep.totals != nil {
+		t.Errorf("totals of the last sheet %v used as the report totals", rep.totals)
+	}
+}
@@ -0,0 +1,82 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Logger receives diagnostic messages generated while building a report.
+
+keysAndValues are alternating key/value pairs with structured information
+such as "sheet", "rows" and "duration". A *slog.Logger satisfies this interface.
+
+Set RepParams.Logger to use a Logger for a report call, when not set messages are printed
+to stdout according to the Vervose and Debug options.
+*/
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+}
+
+// stdLogger is the Logger used when none is provided.
+// Warnings are always printed, Info messages require vervose and Debug messages require debug.
+type stdLogger struct {
+	vervose bool
+	debug   bool
+}
+
+func (l stdLogger) Debug(msg string, keysAndValues ...interface{}) {
+	if l.debug {
+		stdPrint(msg, keysAndValues)
+	}
+}
+
+func (l stdLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.vervose {
+		stdPrint(msg, keysAndValues)
+	}
+}
+
+func (stdLogger) Warn(msg string, keysAndValues ...interface{}) {
+	stdPrint("Warning: "+msg, keysAndValues)
+}
+
+func stdPrint(msg string, keysAndValues []interface{}) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			fmt.Fprintf(&b, " %v=%q", keysAndValues[i], fmt.Sprint(keysAndValues[i+1]))
+		} else {
+			fmt.Fprintf(&b, " %q", fmt.Sprint(keysAndValues[i]))
+		}
+	}
+	fmt.Println(b.String())
+}
+
+// logger returns the Logger to be used for rp.
+func (rp RepParams) logger() Logger {
+	if rp.Logger != nil {
+		return rp.Logger
+	}
+	if rp.Options != nil {
+		return stdLogger{vervose: rp.Options.Vervose, debug: rp.Options.Debug}
+	}
+	return stdLogger{vervose: Vervose, debug: Debug}
+}
+
+// reportsLogger returns the Logger to be used for workbook level messages of a multiple sheets report,
+// that is the first one set on reports.
+func reportsLogger(reports []MultiSheetRep) Logger {
+	for _, k := range reports {
+		if k.Params.Logger != nil {
+			return k.Params.Logger
+		}
+	}
+	if len(reports) > 0 {
+		return reports[0].Params.logger()
+	}
+	return stdLogger{vervose: Vervose, debug: Debug}
+}
@@ -0,0 +1,224 @@
+package xlsrpt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// odsMimeType is the media type of OpenDocument spreadsheets.
+const odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+// odsNamespaces are the namespaces used on the content part.
+const odsNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2" office:version="1.2"`
+
+// writeODS writes the workbook to w as an OpenDocument spreadsheet (.ods).
+// Cells keep their types, number formats and styles. Formulas are converted to OpenFormula and
+// saved along with their value (see evalFormula), autofilters become database ranges.
+func writeODS(w io.Writer, file *xlsx.File) error {
+	styles := newODSStyles()
+
+	var body bytes.Buffer
+	var ranges []string
+	for i, sheet := range file.Sheets {
+		writeODSTable(&body, sheet, styles)
+		if af := sheet.AutoFilter; af != nil {
+			ranges = append(ranges, fmt.Sprintf(`<table:database-range table:name="__Anonymous_Sheet_DB__%d" table:target-range-address="%s" table:display-filter-buttons="true"/>`,
+				i, xmlEscape(odsRangeAddress(sheet.Name, af.TopLeftCell, af.BottomRightCell))))
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	// The mimetype must be the first file of the archive and it can't be compressed
+	part, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(part, odsMimeType); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">`)
+	b.WriteString(`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odsMimeType + `"/>`)
+	b.WriteString(`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>`)
+	b.WriteString(`</manifest:manifest>`)
+	if err := writeZipPart(zw, "META-INF/manifest.xml", b.String()); err != nil {
+		return err
+	}
+
+	b.Reset()
+	b.WriteString(xml.Header)
+	b.WriteString(`<office:document-content ` + odsNamespaces + `>`)
+	b.WriteString(styles.xml())
+	b.WriteString(`<office:body><office:spreadsheet>`)
+	b.Write(body.Bytes())
+	if len(ranges) > 0 {
+		b.WriteString(`<table:database-ranges>` + strings.Join(ranges, "") + `</table:database-ranges>`)
+	}
+	b.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+	if err := writeZipPart(zw, "content.xml", b.String()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeODSTable writes sheet as a table of the content part.
+func writeODSTable(w *bytes.Buffer, sheet *xlsx.Sheet, styles *odsStyles) {
+	fmt.Fprintf(w, `<table:table table:name="%s">`, xmlEscape(sheet.Name))
+
+	for _, col := range sheet.Cols {
+		if col == nil {
+			w.WriteString(`<table:table-column/>`)
+			continue
+		}
+		w.WriteString(`<table:table-column`)
+		if col.Width > 0 {
+			fmt.Fprintf(w, ` table:style-name="%s"`, styles.colStyle(col.Width))
+		}
+		if n := col.Max - col.Min + 1; n > 1 {
+			fmt.Fprintf(w, ` table:number-columns-repeated="%d"`, n)
+		}
+		if col.Hidden {
+			w.WriteString(` table:visibility="collapse"`)
+		}
+		w.WriteString(`/>`)
+	}
+
+	for _, row := range sheet.Rows {
+		w.WriteString(`<table:table-row>`)
+		if row == nil || len(row.Cells) == 0 {
+			// Rows need at least a cell
+			w.WriteString(`<table:table-cell/>`)
+		}
+		if row != nil {
+			for _, cell := range row.Cells {
+				writeODSCell(w, sheet, cell, styles)
+			}
+		}
+		w.WriteString(`</table:table-row>`)
+	}
+	w.WriteString(`</table:table>`)
+}
+
+// writeODSCell writes a cell of a table row.
+func writeODSCell(w *bytes.Buffer, sheet *xlsx.Sheet, cell *xlsx.Cell, styles *odsStyles) {
+	w.WriteString(`<table:table-cell`)
+	if name := styles.cellStyle(cell); name != "" {
+		fmt.Fprintf(w, ` table:style-name="%s"`, name)
+	}
+
+	value := cell.Value
+	if formula := cell.Formula(); formula != "" {
+		fmt.Fprintf(w, ` table:formula="%s"`, xmlEscape(odsFormula(formula)))
+		value = ""
+		if v, ok := evalFormula(sheet, formula); ok {
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	if value == "" {
+		w.WriteString(`/>`)
+		return
+	}
+
+	text := textFormat{display: true}
+	switch {
+	case cell.Type() == xlsx.CellTypeBool:
+		fmt.Fprintf(w, ` office:value-type="boolean" office:boolean-value="%t"`, cell.Bool())
+	case cell.Type() == xlsx.CellTypeNumeric && cell.IsTime():
+		t, err := cell.GetTime(false)
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(w, ` office:value-type="date" office:date-value="%s"`, t.Format("2006-01-02T15:04:05"))
+		value = text.timeText(t)
+	case cell.Type() == xlsx.CellTypeNumeric:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			break
+		}
+		valueType := "float"
+		if parseNumFormat(cell.GetNumberFormat()).percent {
+			valueType = "percentage"
+		}
+		fmt.Fprintf(w, ` office:value-type="%s" office:value="%s"`, valueType, value)
+		value = text.numberText(f, cell.GetNumberFormat())
+	default:
+		w.WriteString(` office:value-type="string"`)
+	}
+	fmt.Fprintf(w, `><text:p>%s</text:p></table:table-cell>`, xmlEscape(value))
+}
+
+// odsCellRef matches the cell references (i.e. B2, $B$2) and ranges (i.e. B2:B9) of a formula.
+var odsCellRef = regexp.MustCompile(`^\$?[A-Za-z]{1,3}\$?[0-9]+(:\$?[A-Za-z]{1,3}\$?[0-9]+)?`)
+
+// odsFormula converts an excel formula (i.e. =SUBTOTAL(109,B2:B9)) to OpenFormula (of:=SUBTOTAL(109;[.B2:.B9])).
+func odsFormula(formula string) string {
+	var b strings.Builder
+	b.WriteString("of:=")
+
+	s := strings.TrimPrefix(formula, "=")
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '"':
+			// String literals are kept as they are
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				end = len(s) - i - 2
+			}
+			b.WriteString(s[i : i+end+2])
+			i += end + 2
+		case c == ',':
+			b.WriteByte(';')
+			i++
+		case (i == 0 || !isIdentChar(s[i-1])) && odsCellRef.MatchString(s[i:]):
+			ref := odsCellRef.FindString(s[i:])
+			if n := i + len(ref); n < len(s) && (s[n] == '(' || isIdentChar(s[n])) {
+				// A function name (i.e. LOG10)
+				b.WriteString(ref)
+			} else {
+				b.WriteString("[." + strings.Replace(ref, ":", ":.", 1) + "]")
+			}
+			i += len(ref)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isIdentChar returns whether c can be part of a function name or cell reference.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || ('0' <= c && c <= '9') || ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z')
+}
+
+// odsRangeAddress returns the address of a range of cells of sheet (i.e. 'Sales'.A4:'Sales'.E10).
+func odsRangeAddress(sheet, topLeft, bottomRight string) string {
+	name := "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+	return name + "." + topLeft + ":" + name + "." + bottomRight
+}
+
+// writeZipPart adds a part with content to the zip archive.
+func writeZipPart(zw *zip.Writer, name, content string) error {
+	part, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
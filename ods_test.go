@@ -0,0 +1,109 @@
+package xlsrpt
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteODS(t *testing.T) {
+	rp := RepParams{
+		RepTitle: "Sales",
+		RepCols: []RepColumns{
+			{Title: "Name"}, {Title: "Count", Aggregate: AggCount}, {Title: "Rate", Hidden: true},
+			{Title: "Share", Format: FormatPercent}, {Title: "Balance", SumFlag: true}, {Title: "Since"}},
+		AltBg:       true,
+		AutoFilter:  true,
+		FooterLabel: "Total",
+		Output:      OutputODS}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, csvTestRows()); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := zr.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Errorf("first part = %q (method %d), want uncompressed mimetype", f.Name, f.Method)
+	}
+
+	var content string
+	for _, f := range zr.File {
+		if f.Name != "content.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = string(b)
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(content))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("content.xml is not well formed: %v", err)
+		}
+	}
+
+	for _, want := range []string{
+		`<table:table table:name="Sales">`,
+		`table:visibility="collapse"`,
+		`<style:table-cell-properties fo:background-color="#4472C4"/><style:text-properties fo:font-weight="bold" fo:color="#FFFFFF"/>`,
+		`<style:table-cell-properties fo:background-color="#B4C6E7"/>`,
+		`office:value-type="string"><text:p>Sales</text:p>`,
+		`office:value-type="float" office:value="3"><text:p>3</text:p>`,
+		`office:value-type="percentage" office:value="0.125"><text:p>12.50%</text:p>`,
+		`office:value-type="date" office:date-value="2024-01-02T15:04:05"`,
+		`<number:percentage-style style:name=`,
+		`<number:text>$</number:text><number:number number:min-integer-digits="1" number:decimal-places="2" number:min-decimal-places="2" number:grouping="true"/>`,
+		`table:formula="of:=SUBTOTAL(109;[.E5:.E6])" office:value-type="float" office:value="1480.25"><text:p>$1,480.25</text:p>`,
+		`table:formula="of:=SUBTOTAL(103;[.B5:.B6])" office:value-type="float" office:value="2">`,
+		`<table:database-range table:name="__Anonymous_Sheet_DB__0" table:target-range-address="&#39;Sales&#39;.A4:&#39;Sales&#39;.F6" table:display-filter-buttons="true"/>`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content.xml has no %s", want)
+		}
+	}
+}
+
+func TestODSFormula(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		{"=SUBTOTAL(109,B2:B9)", "of:=SUBTOTAL(109;[.B2:.B9])"},
+		{"=SUBTOTAL(109,$B$2:$B$9)*0.16", "of:=SUBTOTAL(109;[.$B$2:.$B$9])*0.16"},
+		{`=SUMPRODUCT((B2:B9<>"")/COUNTIF(B2:B9,B2:B9&""))`, `of:=SUMPRODUCT(([.B2:.B9]<>"")/COUNTIF([.B2:.B9];[.B2:.B9]&""))`},
+		{`=IF(A1="A1,B2",LOG10(C3),0)`, `of:=IF([.A1]="A1,B2";LOG10([.C3]);0)`},
+	}
+
+	for _, tt := range tests {
+		if got := odsFormula(tt.formula); got != tt.want {
+			t.Errorf("odsFormula(%q) = %q, want %q", tt.formula, got, tt.want)
+		}
+	}
+}
+
+func TestODSDateParts(t *testing.T) {
+	got := odsDateParts("m/d/yy h:mm")
+	want := `<number:month/><number:text>/</number:text><number:day/><number:text>/</number:text><number:year/>` +
+		`<number:text> </number:text><number:hours/><number:text>:</number:text><number:minutes number:style="long"/>`
+	if got != want {
+		t.Errorf("odsDateParts() = %s, want %s", got, want)
+	}
+}
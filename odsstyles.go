@@ -0,0 +1,256 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// odsStyles collects the automatic styles of an OpenDocument spreadsheet, see writeODS.
+type odsStyles struct {
+	cells      []odsCellStyle
+	cellIDs    map[odsCellStyle]int
+	dataStyles []string // Number format codes
+	dataIDs    map[string]int
+	colWidths  []float64
+}
+
+// odsCellStyle is a cell style of an OpenDocument spreadsheet.
+type odsCellStyle struct {
+	numFmt   string
+	date     bool
+	fill     string
+	bold     bool
+	color    string
+	fontSize int
+	halign   string
+}
+
+// newODSStyles returns an empty odsStyles.
+func newODSStyles() *odsStyles {
+	return &odsStyles{cellIDs: make(map[odsCellStyle]int), dataIDs: make(map[string]int)}
+}
+
+// cellStyle returns the name of the style of cell, empty for the default style. The style is added if it is new.
+func (st *odsStyles) cellStyle(cell *xlsx.Cell) string {
+	var cs odsCellStyle
+
+	s := cell.GetStyle()
+	if s.ApplyFill && s.Fill.PatternType == "solid" {
+		cs.fill = s.Fill.FgColor
+	}
+	if s.ApplyFont {
+		cs.bold = s.Font.Bold
+		cs.color = s.Font.Color
+		if s.Font.Size != xlsx.DefaultFont().Size {
+			cs.fontSize = s.Font.Size
+		}
+	}
+	if s.Alignment.Horizontal != "general" {
+		cs.halign = s.Alignment.Horizontal
+	}
+	if format := cell.GetNumberFormat(); cell.Type() == xlsx.CellTypeNumeric && format != "" && format != "general" {
+		cs.numFmt = format
+		cs.date = cell.IsTime()
+	}
+
+	if cs == (odsCellStyle{}) {
+		return ""
+	}
+	id, ok := st.cellIDs[cs]
+	if !ok {
+		st.cells = append(st.cells, cs)
+		id = len(st.cells)
+		st.cellIDs[cs] = id
+		if cs.numFmt != "" {
+			if _, ok := st.dataIDs[cs.numFmt]; !ok {
+				st.dataStyles = append(st.dataStyles, cs.numFmt)
+				st.dataIDs[cs.numFmt] = len(st.dataStyles)
+			}
+		}
+	}
+	return fmt.Sprintf("ce%d", id)
+}
+
+// colStyle returns the name of the style of a column with width (in characters, as excel widths).
+func (st *odsStyles) colStyle(width float64) string {
+	for i, w := range st.colWidths {
+		if w == width {
+			return fmt.Sprintf("co%d", i+1)
+		}
+	}
+	st.colWidths = append(st.colWidths, width)
+	return fmt.Sprintf("co%d", len(st.colWidths))
+}
+
+// xml returns the automatic styles of the content part.
+func (st *odsStyles) xml() string {
+	var b strings.Builder
+
+	b.WriteString(`<office:automatic-styles>`)
+	for i, width := range st.colWidths {
+		// Excel widths are in characters of about 7 pixels, 96 pixels per inch
+		fmt.Fprintf(&b, `<style:style style:name="co%d" style:family="table-column"><style:table-column-properties style:column-width="%.3fin"/></style:style>`, i+1, width*7/96)
+	}
+	for i, code := range st.dataStyles {
+		b.WriteString(odsDataStyle(fmt.Sprintf("N%d", i+1), code, st.isDate(code)))
+	}
+	for i, cs := range st.cells {
+		fmt.Fprintf(&b, `<style:style style:name="ce%d" style:family="table-cell"`, i+1)
+		if cs.numFmt != "" {
+			fmt.Fprintf(&b, ` style:data-style-name="N%d"`, st.dataIDs[cs.numFmt])
+		}
+		b.WriteString(`>`)
+		if cs.fill != "" {
+			fmt.Fprintf(&b, `<style:table-cell-properties fo:background-color="%s"/>`, cssColor(cs.fill))
+		}
+		if cs.halign != "" {
+			align := map[string]string{"left": "start", "right": "end"}[cs.halign]
+			if align == "" {
+				align = cs.halign
+			}
+			fmt.Fprintf(&b, `<style:paragraph-properties fo:text-align="%s"/>`, xmlEscape(align))
+		}
+		if cs.bold || cs.color != "" || cs.fontSize != 0 {
+			b.WriteString(`<style:text-properties`)
+			if cs.bold {
+				b.WriteString(` fo:font-weight="bold"`)
+			}
+			if cs.color != "" {
+				fmt.Fprintf(&b, ` fo:color="%s"`, cssColor(cs.color))
+			}
+			if cs.fontSize != 0 {
+				fmt.Fprintf(&b, ` fo:font-size="%dpt"`, cs.fontSize)
+			}
+			b.WriteString(`/>`)
+		}
+		b.WriteString(`</style:style>`)
+	}
+	b.WriteString(`</office:automatic-styles>`)
+	return b.String()
+}
+
+// isDate returns whether the number format code is used by date cells.
+func (st *odsStyles) isDate(code string) bool {
+	for _, cs := range st.cells {
+		if cs.numFmt == code {
+			return cs.date
+		}
+	}
+	return false
+}
+
+// odsDataStyle returns the data style named name for an excel number format code.
+func odsDataStyle(name, code string, date bool) string {
+	if date {
+		return fmt.Sprintf(`<number:date-style style:name="%s">%s</number:date-style>`, name, odsDateParts(code))
+	}
+
+	nf := parseNumFormat(code)
+	number := `<number:number number:min-integer-digits="1"`
+	if nf.decimals > 0 {
+		number += fmt.Sprintf(` number:decimal-places="%d" number:min-decimal-places="%d"`, nf.decimals, nf.decimals)
+	} else if nf.decimals == 0 {
+		number += ` number:decimal-places="0"`
+	}
+	if nf.grouping {
+		number += ` number:grouping="true"`
+	}
+	number += `/>`
+
+	var parts string
+	if nf.prefix != "" {
+		parts += `<number:text>` + xmlEscape(nf.prefix) + `</number:text>`
+	}
+	parts += number
+	if nf.suffix != "" {
+		parts += `<number:text>` + xmlEscape(nf.suffix) + `</number:text>`
+	}
+
+	kind := "number-style"
+	if nf.percent {
+		kind = "percentage-style"
+	}
+	return fmt.Sprintf(`<number:%s style:name="%s">%s</number:%s>`, kind, name, parts, kind)
+}
+
+// odsDateParts returns the elements of a date style for an excel date format code (i.e. m/d/yy h:mm).
+func odsDateParts(code string) string {
+	var b strings.Builder
+	var text strings.Builder
+	lastHour := false
+
+	flushText := func() {
+		if text.Len() > 0 {
+			b.WriteString(`<number:text>` + xmlEscape(text.String()) + `</number:text>`)
+			text.Reset()
+		}
+	}
+	lower := strings.ToLower(code)
+	for i := 0; i < len(lower); {
+		c := lower[i]
+		if strings.HasPrefix(lower[i:], "am/pm") {
+			flushText()
+			b.WriteString(`<number:am-pm/>`)
+			i += 5
+			continue
+		}
+		if c == '"' {
+			end := strings.IndexByte(lower[i+1:], '"')
+			if end < 0 {
+				end = len(lower) - i - 1
+			}
+			text.WriteString(code[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+		if c == '\\' && i+1 < len(lower) {
+			text.WriteByte(code[i+1])
+			i += 2
+			continue
+		}
+		if !strings.ContainsRune("ymdhs", rune(c)) {
+			text.WriteByte(code[i])
+			i++
+			continue
+		}
+
+		n := 1
+		for i+n < len(lower) && lower[i+n] == c {
+			n++
+		}
+		style := ""
+		if n > 2 || (n == 2 && c != 'y') {
+			style = ` number:style="long"`
+		}
+		flushText()
+		switch c {
+		case 'y':
+			b.WriteString(`<number:year` + style + `/>`)
+		case 'd':
+			b.WriteString(`<number:day` + style + `/>`)
+		case 'h':
+			b.WriteString(`<number:hours` + style + `/>`)
+		case 's':
+			b.WriteString(`<number:seconds` + style + `/>`)
+		case 'm':
+			// Minutes follow hours or precede seconds, months otherwise
+			next := strings.TrimLeft(lower[i+n:], ":. ")
+			switch {
+			case lastHour || strings.HasPrefix(next, "s"):
+				b.WriteString(`<number:minutes` + style + `/>`)
+			case n > 3:
+				b.WriteString(`<number:month number:style="long" number:textual="true"/>`)
+			case n == 3:
+				b.WriteString(`<number:month number:textual="true"/>`)
+			default:
+				b.WriteString(`<number:month` + style + `/>`)
+			}
+		}
+		lastHour = c == 'h'
+		i += n
+	}
+	flushText()
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package xlsrpt
+
+import "sort"
+
+/*
+Options holds the behavior configuration of a report.
+
+Set RepParams.Options to configure a report call (or a single sheet when using MultiSheetRep).
+When RepParams.Options is nil, the package level configuration variables are used as defaults,
+see DefaultOptions.
+*/
+type Options struct {
+	// LogBench can be used to log benchmark information of report creation (unimplemented).
+	LogBench bool
+
+	// UntouchStrings can be used to leave strings untouched.
+	UntouchStrings bool
+
+	// UntouchCols can be used to set columns that must not be formatted.
+	UntouchCols []string
+
+	// Vervose prints information about Excel File generation when no Logger is set.
+	Vervose bool
+
+	// Debug prints debug information about Excel File generation when no Logger is set.
+	Debug bool
+
+	// NullText is the cell text for NULL values (i.e. "NULL" or "-"), cells are left blank when empty.
+	// Nil pointers and sql.Null* types with no value are NULL values.
+	NullText string
+
+	// Dialect overrides the Dialect registered for the database driver (see RegisterDialect).
+	Dialect Dialect
+
+	// Concurrency is the number of sheets of multi-sheet workbooks (ExcelMultiSheet, ExcelMultiSheetFromDB and
+	// their variants, except streamed ones) generated at the same time, the Options of the first report are used.
+	// Queries of reports using the same *sql.DB are also limited by its maximum number of open connections
+	// (see sql.DB.SetMaxOpenConns). Sheets are added to the workbook in the order of the reports regardless
+	// of this setting.
+	//
+	// When Concurrency is greater than 1 each MultiSheetRep must have its own Data, LoadRows() of different
+	// reports may be called at the same time. Sheets are generated one after the other when it is 0 or 1.
+	Concurrency int
+}
+
+// DefaultOptions returns Options with the current values of the package level configuration variables
+// (LogBench, UntouchStrings, UntouchCols, Vervose and Debug).
+func DefaultOptions() *Options {
+	return &Options{
+		LogBench:       LogBench,
+		UntouchStrings: UntouchStrings,
+		UntouchCols:    append([]string(nil), UntouchCols...),
+		Vervose:        Vervose,
+		Debug:          Debug,
+	}
+}
+
+// options returns the Options to be used for rp.
+// A copy is returned so the caller's values are never modified (UntouchCols is sorted).
+func (rp RepParams) options() *Options {
+	var opts Options
+	if rp.Options != nil {
+		opts = *rp.Options
+		opts.UntouchCols = append([]string(nil), opts.UntouchCols...)
+	} else {
+		opts = *DefaultOptions()
+	}
+	sort.Strings(opts.UntouchCols)
+	return &opts
+}
+
+// untouchCol returns true if col must not be formatted.
+func (opts *Options) untouchCol(col string) bool {
+	i := sort.SearchStrings(opts.UntouchCols, col)
+	return i < len(opts.UntouchCols) && opts.UntouchCols[i] == col
+}
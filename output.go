@@ -0,0 +1,272 @@
+package xlsrpt
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+Output - File format of a report, see RepParams.Output.
+
+Every format is rendered from the same sheets generated for the excel workbook, so a report definition
+(RepParams with ReportData or a query) gives the same rows and values on any of them.
+*/
+type Output int
+
+// Output formats. OutputAuto chooses the format by the extension of the file path, xlsx when it has no known extension.
+const (
+	OutputAuto Output = iota
+	OutputXLSX
+	OutputCSV
+	OutputHTML
+	OutputODS
+	OutputJSON
+	OutputNDJSON
+)
+
+// outputExts are the file extensions of the output formats.
+var outputExts = map[Output]string{
+	OutputXLSX:   ".xlsx",
+	OutputCSV:    ".csv",
+	OutputHTML:   ".html",
+	OutputODS:    ".ods",
+	OutputJSON:   ".json",
+	OutputNDJSON: ".ndjson",
+}
+
+// outputFor returns the format to be used for out and filePath, see OutputAuto.
+func outputFor(out Output, filePath string) Output {
+	if out != OutputAuto {
+		return out
+	}
+	ext := filepath.Ext(filePath)
+	for o, e := range outputExts {
+		if strings.EqualFold(ext, e) {
+			return o
+		}
+	}
+	return OutputXLSX
+}
+
+// reportsParams returns the params holding the output format and options of a multiple sheets report,
+// those of the first report.
+func reportsParams(reports []MultiSheetRep) RepParams {
+	if len(reports) == 0 {
+		return RepParams{}
+	}
+	return reports[0].Params
+}
+
+// outputPath adds the extension of out to filePath if needed.
+func outputPath(filePath string, out Output, log Logger) string {
+	if out == OutputXLSX {
+		return xlsxPath(filePath, log)
+	}
+	if ext := outputExts[out]; !strings.EqualFold(filepath.Ext(filePath), ext) {
+		filePath += ext
+	}
+	return filePath
+}
+
+// saveOutput saves the workbook to filePath in the format chosen by rp.Output and the extension of filePath
+// (see outputFor), rp also holds the format options.
+func saveOutput(file *xlsx.File, filePath string, rp RepParams, log Logger) error {
+	out := outputFor(rp.Output, filePath)
+	filePath = outputPath(filePath, out, log)
+	if out == OutputXLSX {
+		return file.Save(filePath)
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	return errors.Join(writeOutput(f, file, out, rp), f.Close())
+}
+
+// writeOutput writes the workbook to w in the out format, rp holds the format options.
+func writeOutput(w io.Writer, file *xlsx.File, out Output, rp RepParams) error {
+	switch out {
+	case OutputCSV:
+		return writeCSV(w, file, rp.CSV)
+	case OutputHTML:
+		return writeHTML(w, file, rp.HTML)
+	case OutputODS:
+		return writeODS(w, file)
+	case OutputJSON:
+		return writeJSON(w, file, rp)
+	case OutputNDJSON:
+		return writeNDJSON(w, file, rp)
+	}
+	return file.Write(w)
+}
+
+// textFormat defines how cell values are written on text based formats.
+type textFormat struct {
+	decimal    rune   // Decimal separator, '.' when not set
+	dateFormat string // time layout for dates, see timeText
+	display    bool   // Numbers as shown by excel, rounded to their format with thousands separators and currency signs
+}
+
+// cellText returns the value of a report cell as text.
+// Numbers are written as saved on the cell unless tf.display is set, see numberText.
+func (tf textFormat) cellText(cell *xlsx.Cell) string {
+	if cell.Type() == xlsx.CellTypeBool {
+		if cell.Bool() {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	if cell.Type() != xlsx.CellTypeNumeric || cell.Value == "" {
+		return cell.Value
+	}
+	if cell.IsTime() {
+		t, err := cell.GetTime(false)
+		if err != nil {
+			return cell.Value
+		}
+		return tf.timeText(t)
+	}
+	f, err := cell.Float()
+	if err != nil {
+		return cell.Value
+	}
+	return tf.numberText(f, cell.GetNumberFormat())
+}
+
+// timeText returns t formatted with tf.dateFormat.
+// When not set, dates are written as 2006-01-02 adding the time (15:04:05) if it is not midnight.
+func (tf textFormat) timeText(t time.Time) string {
+	if tf.dateFormat != "" {
+		return t.Format(tf.dateFormat)
+	}
+	// Excel times are rounded to the millisecond
+	t = t.Round(time.Millisecond)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// numberText returns f formatted as text using the excel number format code when tf.display is set.
+// Otherwise f is written with all its digits (percents as fractions), so no data is lost.
+func (tf textFormat) numberText(f float64, code string) string {
+	if !tf.display {
+		if f == 0 {
+			f = 0 // No "-0"
+		}
+		s := strconv.FormatFloat(f, 'f', -1, 64)
+		if tf.decimal != 0 && tf.decimal != '.' {
+			s = strings.Replace(s, ".", string(tf.decimal), 1)
+		}
+		return s
+	}
+
+	nf := parseNumFormat(code)
+	decimals := nf.decimals
+	if nf.percent {
+		f *= 100
+	}
+
+	neg := f < 0
+	if neg {
+		f = -f
+	}
+	if decimals >= 0 {
+		// Excel rounds half away from zero
+		p := math.Pow10(decimals)
+		f = math.Round(f*p) / p
+	}
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+
+	decimal, thousands := ".", ","
+	if tf.decimal != 0 && tf.decimal != '.' {
+		decimal = string(tf.decimal)
+		if tf.decimal == ',' {
+			thousands = "."
+		}
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if nf.grouping {
+		intPart = groupThousands(intPart, thousands)
+	}
+	s = intPart
+	if hasFrac {
+		s += decimal + fracPart
+	}
+
+	zero := strings.Trim(s, "0"+decimal) == ""
+
+	s = nf.prefix + s + nf.suffix
+	if neg && !zero {
+		s = "-" + s
+	}
+	return s
+}
+
+// groupThousands adds sep between each group of three digits of the integer digits.
+func groupThousands(digits, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// numFormat is the layout of an excel number format code (only its first section is used).
+type numFormat struct {
+	prefix, suffix string // Literal text around the number (i.e. the currency sign)
+	decimals       int    // Decimal places, -1 for general formats
+	grouping       bool   // Uses thousands separators
+	percent        bool
+}
+
+// parseNumFormat returns the layout of the number format code.
+func parseNumFormat(code string) numFormat {
+	code, _, _ = strings.Cut(code, ";")
+	first := strings.IndexAny(code, "#0")
+	if first < 0 || strings.EqualFold(code, "general") {
+		return numFormat{decimals: -1, percent: strings.Contains(code, "%")}
+	}
+	last := strings.LastIndexAny(code, "#0")
+
+	nf := numFormat{
+		prefix: formatLiteral(code[:first]),
+		suffix: formatLiteral(code[last+1:]),
+	}
+	body := code[first : last+1]
+	nf.grouping = strings.Contains(body, ",")
+	if _, frac, ok := strings.Cut(body, "."); ok {
+		nf.decimals = strings.Count(frac, "0") + strings.Count(frac, "#")
+	}
+	nf.percent = strings.Contains(nf.suffix, "%")
+	return nf
+}
+
+// formatLiteral returns the text shown for the literal part of a number format code.
+func formatLiteral(s string) string {
+	s = strings.NewReplacer(`"`, "", `\`, "", "[$", "", "]", "").Replace(s)
+	if i := strings.Index(s, "-"); i > 0 && strings.HasPrefix(s, "$") {
+		// Locale of [$$-409] like codes
+		s = s[:i]
+	}
+	return s
+}
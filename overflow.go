@@ -0,0 +1,71 @@
+package xlsrpt
+
+import (
+	"errors"
+
+	"github.com/tealeg/xlsx"
+)
+
+// OverflowMode defines what to do when the rows of a report don't fit in a sheet, see RepParams.Overflow.
+type OverflowMode int
+
+// Overflow modes for RepParams.Overflow.
+const (
+	OverflowSheets     OverflowMode = iota // Rows continue on a new sheet, each one with its own totals
+	OverflowGrandTotal                     // Same as OverflowSheets plus a grand total row on the last sheet
+	OverflowError                          // The report fails with ErrRowLimit
+)
+
+// ErrRowLimit is returned when the rows of a report don't fit in a sheet and RepParams.Overflow is OverflowError.
+var ErrRowLimit = errors.New("excel sheet row limit exceeded")
+
+// maxSheetRows is the number of rows of an excel sheet.
+var maxSheetRows = xlsx.Excel2006MaxRowCount
+
+// sheetSink adds the sheets of a report, see fillSheetFromDB.
+type sheetSink interface {
+	// newSheet adds a sheet named name, rows are added to the returned sheet.
+	newSheet(name string) (*xlsx.Sheet, error)
+	// flush is called after each data row is added to the sheet.
+	flush() error
+}
+
+// fileSheets adds the sheets of a report to an in memory workbook.
+type fileSheets struct {
+	file *xlsx.File
+}
+
+func (fs fileSheets) newSheet(name string) (*xlsx.Sheet, error) {
+	return fs.file.AddSheet(name)
+}
+
+func (fs fileSheets) flush() error {
+	return nil
+}
+
+// grandTotalLabel is the label of the row added by addGrandTotalRow.
+const grandTotalLabel = "Grand Total"
+
+// addGrandTotalRow adds a row with the totals of all the sheets of a report, grand holds the values
+// of each column. Columns with a custom Formula are left empty.
+func addGrandTotalRow(sheet *xlsx.Sheet, cols []column, grand []*pivotAcc) {
+	row := sheet.AddRow()
+
+	for c, col := range cols {
+		cell := row.AddCell()
+		if col.Formula == "" && col.aggregate() != "" {
+			if v, ok := grand[c].value(col.aggregate()); ok {
+				cell.SetFloatWithFormat(v, col.totalFormat())
+			}
+			totalStyle(cell)
+			continue
+		}
+		footerStyle(cell)
+		if c == 0 {
+			cell.Value = grandTotalLabel
+			s := cell.GetStyle()
+			s.Font.Bold = true
+			s.ApplyFont = true
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+// setMaxSheetRows sets the sheet row limit for the duration of the test.
+func setMaxSheetRows(t *testing.T, n int) {
+	old := maxSheetRows
+	maxSheetRows = n
+	t.Cleanup(func() { maxSheetRows = old })
+}
+
+func overflowTestDB(nrows int) fakeResult {
+	res := fakeResult{cols: []string{"Name", "Amount"}, types: []string{"VARCHAR", "INT"}}
+	for r := 1; r <= nrows; r++ {
+		res.rows = append(res.rows, []driver.Value{"Row " + strconv.Itoa(r), strconv.Itoa(r)})
+	}
+	return res
+}
+
+func TestOverflowSheets(t *testing.T) {
+	setMaxSheetRows(t, 10)
+	db := fakeDB(map[string]fakeResult{"overflow": overflowTestDB(20)})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepTitle:    "Overflow",
+		RepSheet:    "Overflow",
+		Query:       "overflow",
+		RepCols:     []RepColumns{{Title: "Name"}, {Title: "Amount", Aggregate: AggAverage}},
+		FooterLabel: "Total",
+		Overflow:    OverflowGrandTotal,
+		Logger:      discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each sheet has 3 title rows, the header row, 4 data rows, the footer row and room for the grand total
+	names := []string{"Overflow", "Overflow (2)", "Overflow (3)", "Overflow (4)", "Overflow (5)"}
+	if len(file.Sheets) != len(names) {
+		t.Fatalf("workbook has %d sheets, want %d", len(file.Sheets), len(names))
+	}
+	for i, sheet := range file.Sheets {
+		if sheet.Name != names[i] {
+			t.Errorf("sheet %d name = %q, want %q", i+1, sheet.Name, names[i])
+		}
+		if len(sheet.Rows) > 10 {
+			t.Errorf("sheet %q has %d rows", sheet.Name, len(sheet.Rows))
+		}
+		if got := sheet.Cell(3, 0).Value; got != "Name" {
+			t.Errorf("sheet %q header = %q", sheet.Name, got)
+		}
+		if got := sheet.Cell(8, 1).Formula(); got != "=SUBTOTAL(101,B5:B8)" {
+			t.Errorf("sheet %q footer = %q", sheet.Name, got)
+		}
+	}
+	if got := file.Sheets[1].Cell(4, 0).Value; got != "Row 5" {
+		t.Errorf("first row of second sheet = %q, want %q", got, "Row 5")
+	}
+
+	last := file.Sheets[len(file.Sheets)-1]
+	grand := last.Rows[len(last.Rows)-1]
+	if grand.Cells[0].Value != "Grand Total" || grand.Cells[1].Value != "10.5" {
+		t.Errorf("grand total row = %q, %q", grand.Cells[0].Value, grand.Cells[1].Value)
+	}
+}
+
+func TestOverflowError(t *testing.T) {
+	setMaxSheetRows(t, 10)
+	db := fakeDB(map[string]fakeResult{"overflow": overflowTestDB(20)})
+	defer db.Close()
+
+	rp := RepParams{RepSheet: "Overflow", Query: "overflow", Overflow: OverflowError, Logger: discardLogger{}}
+	if err := genSheetFromDB(context.Background(), xlsx.NewFile(), rp, db); !errors.Is(err, ErrRowLimit) {
+		t.Errorf("err = %v, want ErrRowLimit", err)
+	}
+
+	rows := make([]groupTestRow, 20)
+	if err := genSheet(context.Background(), xlsx.NewFile(), RepParams{RepSheet: "Struct", Logger: discardLogger{}}, rows); !errors.Is(err, ErrRowLimit) {
+		t.Errorf("struct report err = %v, want ErrRowLimit", err)
+	}
+}
+
+func TestOverflowStream(t *testing.T) {
+	setMaxSheetRows(t, 10)
+	db := fakeDB(map[string]fakeResult{"overflow": overflowTestDB(9)})
+	defer db.Close()
+
+	var buf bytes.Buffer
+	rp := RepParams{RepSheet: "Stream", Query: "overflow", NoTitleRow: true, AutoFilter: true, GroupBy: []string{"Name"}, Logger: discardLogger{}}
+	if err := WriteExcelFromDBStream(context.Background(), &buf, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	file, err := xlsx.OpenBinary(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each data row has a subtotal row, room is kept for the subtotals and the footer
+	if len(file.Sheets) != 3 {
+		t.Fatalf("workbook has %d sheets, want 3", len(file.Sheets))
+	}
+	for _, sheet := range file.Sheets {
+		if len(sheet.Rows) > 10 {
+			t.Errorf("sheet %q has %d rows", sheet.Name, len(sheet.Rows))
+		}
+	}
+	if got := file.Sheet["Stream (3)"].Cell(1, 0).Value; got != "Row 9" {
+		t.Errorf("first row of third sheet = %q, want %q", got, "Row 9")
+	}
+}
@@ -0,0 +1,253 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+Pivot - Cross-tab definition for RepParams.Pivot.
+
+Instead of a row for each record, the sheet has a row for each value of the Rows column and a column
+for each value of the Columns column. Cells show the Aggregate (one of the Agg constants, AggSum when not set)
+of the Values column for the records with those values, along with row and column totals.
+Rows, Columns and Values are matched by title or by struct field or query column name, ignoring case.
+
+Format is the number format of the cells, when not set the format of the Values column is used
+(currency for sums of columns with no Format). The totals header and row are titled with RepParams.FooterLabel,
+"Total" when not set.
+*/
+type Pivot struct {
+	Rows      string
+	Columns   string
+	Values    string
+	Aggregate string
+	Format    string
+}
+
+// pivotTable accumulates the records of a pivot report.
+type pivotTable struct {
+	rows, cols, values column
+	agg                string
+	format             string
+
+	rowKeys, colKeys []string
+	cells            map[[2]string]*pivotAcc
+	rowTotals        map[string]*pivotAcc
+	colTotals        map[string]*pivotAcc
+	total            pivotAcc
+}
+
+// pivotAcc holds the values needed to calculate the aggregates of a pivot cell.
+type pivotAcc struct {
+	count    int // Non null values
+	nums     int // Numeric values
+	sum      float64
+	min, max float64
+	distinct map[string]bool // Only for AggCountDistinct, see newPivotAcc
+}
+
+// newPivotAcc returns an empty pivotAcc for the aggregate agg.
+// Distinct values are only kept for AggCountDistinct, since they may take a lot of memory.
+func newPivotAcc(agg string) *pivotAcc {
+	if agg == AggCountDistinct {
+		return &pivotAcc{distinct: make(map[string]bool)}
+	}
+	return &pivotAcc{}
+}
+
+// newPivotTable returns the pivotTable of p for a report with columns cols.
+func newPivotTable(cols []column, p Pivot) (*pivotTable, error) {
+	pt := &pivotTable{
+		cells:     make(map[[2]string]*pivotAcc),
+		rowTotals: make(map[string]*pivotAcc),
+		colTotals: make(map[string]*pivotAcc),
+	}
+
+	for _, f := range []struct {
+		name string
+		col  *column
+		desc string
+	}{{p.Rows, &pt.rows, "Rows"}, {p.Columns, &pt.cols, "Columns"}, {p.Values, &pt.values, "Values"}} {
+		c := groupColumn(cols, f.name)
+		if c < 0 {
+			return nil, fmt.Errorf("Pivot %s column %q not found", f.desc, f.name)
+		}
+		*f.col = cols[c]
+	}
+
+	valueCol := RepColumns{Title: pt.values.Title, Aggregate: p.Aggregate, Format: pt.values.Format, TotalFormat: p.Format}
+	if valueCol.Aggregate == "" {
+		valueCol.Aggregate = AggSum
+	}
+	pt.agg = valueCol.aggregate()
+	if _, ok := subtotalFunctions[pt.agg]; !ok && pt.agg != AggCountDistinct {
+		return nil, fmt.Errorf("Pivot: unknown aggregate %q", p.Aggregate)
+	}
+	pt.format = valueCol.totalFormat()
+	pt.total = *newPivotAcc(pt.agg)
+	return pt, nil
+}
+
+// add adds a record to the pivot, value returns the value of a column of the record.
+func (pt *pivotTable) add(value func(col column) reflect.Value) {
+	rowKey := groupKey(value(pt.rows))
+	colKey := groupKey(value(pt.cols))
+
+	cell, ok := pt.cells[[2]string{rowKey, colKey}]
+	if !ok {
+		cell = newPivotAcc(pt.agg)
+		pt.cells[[2]string{rowKey, colKey}] = cell
+	}
+	if _, ok := pt.rowTotals[rowKey]; !ok {
+		pt.rowKeys = append(pt.rowKeys, rowKey)
+		pt.rowTotals[rowKey] = newPivotAcc(pt.agg)
+	}
+	if _, ok := pt.colTotals[colKey]; !ok {
+		pt.colKeys = append(pt.colKeys, colKey)
+		pt.colTotals[colKey] = newPivotAcc(pt.agg)
+	}
+
+	val := value(pt.values)
+	for _, acc := range []*pivotAcc{cell, pt.rowTotals[rowKey], pt.colTotals[colKey], &pt.total} {
+		acc.add(val)
+	}
+}
+
+// add adds val to the accumulator, null values are ignored.
+func (acc *pivotAcc) add(val reflect.Value) {
+	v, ok := unwrapValue(val)
+	if !ok {
+		return
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		v = reflect.ValueOf(string(v.Bytes()))
+	}
+
+	acc.count++
+	if acc.distinct != nil {
+		acc.distinct[groupKey(v)] = true
+	}
+
+	if f, ok := valueFloat(v); ok {
+		if acc.nums == 0 || f < acc.min {
+			acc.min = f
+		}
+		if acc.nums == 0 || f > acc.max {
+			acc.max = f
+		}
+		acc.nums++
+		acc.sum += f
+	}
+}
+
+// value returns the aggregate agg of the accumulator, false if there are no values for it.
+func (acc *pivotAcc) value(agg string) (float64, bool) {
+	switch agg {
+	case AggCount:
+		return float64(acc.count), true
+	case AggCountDistinct:
+		return float64(len(acc.distinct)), true
+	}
+	if acc.nums == 0 {
+		return 0, false
+	}
+	switch agg {
+	case AggAverage:
+		return acc.sum / float64(acc.nums), true
+	case AggMin:
+		return acc.min, true
+	case AggMax:
+		return acc.max, true
+	}
+	return acc.sum, true
+}
+
+// write adds the pivot rows to sheet, after the title rows.
+func (pt *pivotTable) write(sheet *xlsx.Sheet, rp RepParams, startRow int) {
+	sortPivotKeys(pt.rowKeys)
+	sortPivotKeys(pt.colKeys)
+
+	label := rp.FooterLabel
+	if label == "" {
+		label = "Total"
+	}
+
+	// Pivot columns: row values, a column for each value of the Columns column and the row totals
+	cols := []column{{RepColumns: RepColumns{Title: pt.rows.Title, Width: pt.rows.Width}}}
+	for _, key := range pt.colKeys {
+		cols = append(cols, column{RepColumns: RepColumns{Title: key, Width: pt.values.Width}})
+	}
+	cols = append(cols, column{RepColumns: RepColumns{Title: label, Width: pt.values.Width}})
+	addHeaderRow(sheet, cols)
+
+	flag := false
+	for i, rowKey := range pt.rowKeys {
+		if rp.AltBg {
+			flag = i%2 == 0
+		}
+		row := sheet.AddRow()
+		altBgColor(CellStr(rowKey).addCell(row), flag)
+		for _, colKey := range pt.colKeys {
+			cell := row.AddCell()
+			if acc, ok := pt.cells[[2]string{rowKey, colKey}]; ok {
+				pt.setValue(cell, acc)
+			}
+			altBgColor(cell, flag)
+		}
+		cell := row.AddCell()
+		pt.setValue(cell, pt.rowTotals[rowKey])
+		altBgColor(cell, flag)
+		s := cell.GetStyle()
+		s.Font.Bold = true
+		s.ApplyFont = true
+	}
+
+	if len(pt.rowKeys) != 0 {
+		row := sheet.AddRow()
+		cell := row.AddCell()
+		footerStyle(cell)
+		cell.Value = label
+		s := cell.GetStyle()
+		s.Font.Bold = true
+		s.ApplyFont = true
+		for _, colKey := range pt.colKeys {
+			cell = row.AddCell()
+			pt.setValue(cell, pt.colTotals[colKey])
+			totalStyle(cell)
+		}
+		cell = row.AddCell()
+		pt.setValue(cell, &pt.total)
+		totalStyle(cell)
+	}
+
+	setColumns(sheet, cols)
+	if rp.AutoFilter {
+		setAutoFilter(sheet, startRow, len(cols), len(pt.rowKeys))
+	}
+}
+
+// setValue sets the cell to the aggregate of acc, the cell is left empty if there are no values.
+func (pt *pivotTable) setValue(cell *xlsx.Cell, acc *pivotAcc) {
+	if v, ok := acc.value(pt.agg); ok {
+		cell.SetFloatWithFormat(v, pt.format)
+	}
+}
+
+// sortPivotKeys sorts the values of a pivot dimension, numerically when all of them are numbers.
+func sortPivotKeys(keys []string) {
+	nums := make(map[string]float64, len(keys))
+	for _, k := range keys {
+		f, err := strconv.ParseFloat(k, 64)
+		if err != nil {
+			sort.Strings(keys)
+			return
+		}
+		nums[k] = f
+	}
+	sort.Slice(keys, func(i, j int) bool { return nums[keys[i]] < nums[keys[j]] })
+}
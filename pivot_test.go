@@ -0,0 +1,122 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+type pivotTestRow struct {
+	Region CellStr
+	Year   CellInt
+	Amount CellDecimal
+}
+
+func TestPivot(t *testing.T) {
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepSheet:   "Pivot",
+		NoTitleRow: true,
+		AltBg:      true,
+		Pivot:      &Pivot{Rows: "Region", Columns: "year", Values: "Amount", Format: FormatNumeric},
+		Logger:     discardLogger{}}
+	rows := []pivotTestRow{
+		{"South", 2021, 5}, {"North", 2021, 1}, {"North", 2020, 2}, {"North", 2021, 3}, {"South", 2020, 4}, {"East", 2021, 6}}
+	if err := genSheet(context.Background(), file, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Region", "2020", "2021", "Total"},
+		{"East", "", "6", "6"},
+		{"North", "2", "4", "6"},
+		{"South", "4", "5", "9"},
+		{"Total", "6", "15", "21"},
+	}
+	sheet := file.Sheets[0]
+	if len(sheet.Rows) != len(want) {
+		t.Fatalf("sheet has %d rows, want %d", len(sheet.Rows), len(want))
+	}
+	for r, cells := range want {
+		for c, v := range cells {
+			if got := sheet.Cell(r, c).Value; got != v {
+				t.Errorf("cell %s = %q, want %q", CellRef(c, r+1), got, v)
+			}
+		}
+	}
+	if got := sheet.Cell(1, 0).GetStyle().Fill.FgColor; got != "00B4C6E7" {
+		t.Errorf("AltBg fill = %q", got)
+	}
+}
+
+func TestPivotFromDB(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"pivot": {
+			cols:  []string{"branch", "product", "qty"},
+			types: []string{"VARCHAR", "VARCHAR", "INT"},
+			rows: [][]driver.Value{
+				{[]byte("B1"), []byte("Pens"), "2"}, {[]byte("B1"), []byte("Pens"), "4"},
+				{[]byte("B2"), []byte("Ink"), "5"}, {[]byte("B2"), []byte("Pens"), nil}}}})
+	defer db.Close()
+
+	file := xlsx.NewFile()
+	rp := RepParams{
+		RepSheet:    "Pivot",
+		Query:       "pivot",
+		NoTitleRow:  true,
+		FooterLabel: "All",
+		Pivot:       &Pivot{Rows: "branch", Columns: "product", Values: "qty", Aggregate: AggAverage},
+		Logger:      discardLogger{}}
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"branch", "Ink", "Pens", "All"},
+		{"B1", "", "3", "3"},
+		{"B2", "5", "", "5"},
+		{"All", "5", "3", "3.6666666666666665"},
+	}
+	sheet := file.Sheets[0]
+	for r, cells := range want {
+		for c, v := range cells {
+			if got := sheet.Cell(r, c).Value; got != v {
+				t.Errorf("cell %s = %q, want %q", CellRef(c, r+1), got, v)
+			}
+		}
+	}
+}
+
+func TestPivotErrors(t *testing.T) {
+	for _, p := range []Pivot{
+		{Rows: "Region", Columns: "Country", Values: "Amount"},
+		{Rows: "Region", Columns: "Year", Values: "Amount", Aggregate: "median"},
+	} {
+		rp := RepParams{RepSheet: "Pivot", Pivot: &p, Logger: discardLogger{}}
+		if err := genSheet(context.Background(), xlsx.NewFile(), rp, []pivotTestRow{}); err == nil {
+			t.Errorf("%+v returned no error", p)
+		}
+	}
+}
+
+func TestPivotAccDistinct(t *testing.T) {
+	sum, distinct := newPivotAcc(AggSum), newPivotAcc(AggCountDistinct)
+	for _, v := range []float64{1, 2, 2, 3} {
+		sum.add(reflect.ValueOf(v))
+		distinct.add(reflect.ValueOf(v))
+	}
+
+	// Distinct values are only kept when they are needed
+	if sum.distinct != nil {
+		t.Errorf("AggSum accumulator keeps %d distinct values", len(sum.distinct))
+	}
+	if v, _ := sum.value(AggSum); v != 8 {
+		t.Errorf("sum = %v, want 8", v)
+	}
+	if v, _ := distinct.value(AggCountDistinct); v != 3 {
+		t.Errorf("count distinct = %v, want 3", v)
+	}
+}
@@ -0,0 +1,69 @@
+package xlsrpt
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structScanner scans query rows into structs, mapping each column to a struct field.
+type structScanner struct {
+	fields []int // Index of the struct field for each column
+}
+
+/*
+newStructScanner maps the columns cols to the fields of struct type t.
+
+A column matches a field when it is equal to the field's db tag or to the field name,
+ignoring case and underscores (customer_number matches CustomerNumber).
+Fields tagged db:"-" and unexported fields are never matched, columns with no field are an error.
+*/
+func newStructScanner(t reflect.Type, cols []string) (*structScanner, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%v is not a struct", t)
+	}
+
+	byName := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		if tag != "" {
+			byName[normalizeName(tag)] = i
+		} else if _, ok := byName[normalizeName(f.Name)]; !ok {
+			byName[normalizeName(f.Name)] = i
+		}
+	}
+
+	var unmatched []string
+	s := &structScanner{fields: make([]int, len(cols))}
+	for i, col := range cols {
+		f, ok := byName[normalizeName(col)]
+		if !ok {
+			unmatched = append(unmatched, col)
+		}
+		s.fields[i] = f
+	}
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf("no field of %v for columns %s", t, strings.Join(unmatched, ", "))
+	}
+
+	return s, nil
+}
+
+// scan scans the current row into dest, which must be an addressable struct.
+func (s *structScanner) scan(rows *sql.Rows, dest reflect.Value) error {
+	ptrs := make([]interface{}, len(s.fields))
+	for i, f := range s.fields {
+		ptrs[i] = dest.Field(f).Addr().Interface()
+	}
+	return rows.Scan(ptrs...)
+}
+
+// normalizeName returns name in lower case and without underscores.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
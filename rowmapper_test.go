@@ -0,0 +1,70 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type mapperTestRow struct {
+	Created  CellDate
+	Name     CellStr `db:"full_name"`
+	Number   CellInt
+	Balance  sql.NullFloat64
+	Notes    *string
+	internal string
+}
+
+func TestSliceReportAutomaticMapping(t *testing.T) {
+	created := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db := fakeDB(map[string]fakeResult{
+		"mapper": {
+			cols: []string{"CREATED", "FULL_NAME", "number", "Balance", "notes"},
+			rows: [][]driver.Value{
+				{created, "John", int64(10), 1.5, "vip"},
+				{created, "Jane", int64(20), nil, nil}}}})
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "mapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var r SliceReport[mapperTestRow]
+	if err := r.LoadRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Rows) != 2 {
+		t.Fatalf("loaded %d rows, want 2", len(r.Rows))
+	}
+	got := r.Rows[0]
+	if time.Time(got.Created) != created || got.Name != "John" || got.Number != 10 || got.Balance.Float64 != 1.5 || *got.Notes != "vip" {
+		t.Errorf("row 0 = %+v", got)
+	}
+	got = r.Rows[1]
+	if got.Balance.Valid || got.Notes != nil {
+		t.Errorf("row 1 = %+v, want NULL Balance and Notes", got)
+	}
+}
+
+func TestSliceReportUnmatchedColumn(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{
+		"unmatched": {cols: []string{"Number", "Unknown", "internal"}}})
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "unmatched")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var r SliceReport[mapperTestRow]
+	err = r.LoadRows(rows)
+	if err == nil || err.Error() != "no field of xlsrpt.mapperTestRow for columns Unknown, internal" {
+		t.Errorf("LoadRows() error = %v", err)
+	}
+}
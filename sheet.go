@@ -0,0 +1,171 @@
+package xlsrpt
+
+import (
+	"github.com/tealeg/xlsx"
+)
+
+// Styling and layout shared by all report sheets.
+
+// Fill colors of the report rows, also used to tell the rows apart (see sheetRowKinds).
+const (
+	headerFill = "004472C4"
+	altBgFill  = "00B4C6E7"
+	footerFill = "00D0CECE"
+)
+
+// rowKind is the role of a row in a report sheet.
+type rowKind int
+
+const (
+	titleRow      rowKind = iota // Title and the empty rows around it
+	headerRow                    // Column titles
+	dataRow                      // A record (or a pivot row)
+	subtotalRow                  // Totals of a group, see RepParams.GroupBy
+	footerRow                    // Totals of the sheet
+	grandTotalRow                // Totals of all the sheets of the report, see addGrandTotalRow
+)
+
+// addTitleRows adds the report title (unless rp.NoTitleRow is set) and returns
+// the row number of the column titles row.
+func addTitleRows(sheet *xlsx.Sheet, rp RepParams) (startRow int) {
+	startRow = 1
+	if !rp.NoTitleRow {
+		startRow = 4
+		// Add Report Title
+		sheet.AddRow() // Skip a Row
+		cell := sheet.AddRow().AddCell()
+		s := cell.GetStyle()
+		s.Font.Size = 18
+		s.Font.Bold = true
+		s.ApplyFont = true
+		cell.Value = rp.RepTitle
+		sheet.AddRow()
+	}
+	return startRow
+}
+
+// addHeaderRow adds the column titles row.
+func addHeaderRow(sheet *xlsx.Sheet, cols []column) {
+	row := sheet.AddRow()
+	for _, k := range cols {
+		cell := row.AddCell()
+		s := cell.GetStyle()
+		s.Fill.PatternType = "solid"
+		s.Fill.FgColor = headerFill
+		s.Font.Color = "00FFFFFF"
+		s.Font.Bold = true
+		s.ApplyFill = true
+		s.ApplyFont = true
+		cell.Value = k.Title
+	}
+}
+
+// setColumns sets width and visibility of the sheet columns.
+func setColumns(sheet *xlsx.Sheet, cols []column) {
+	for c, col := range cols {
+		width := col.Width
+		if width == 0 {
+			width = 28.0
+		}
+		_ = sheet.SetColWidth(c, c, width)
+		sheet.Col(c).Hidden = col.Hidden
+	}
+}
+
+// setAutoFilter sets the autofilter for a table of ncols columns and nrows rows (plus the titles row).
+func setAutoFilter(sheet *xlsx.Sheet, startRow, ncols, nrows int) {
+	tpCell := CellRef(0, startRow)
+	brCell := CellRef(ncols-1, startRow+nrows)
+	sheet.AutoFilter = &xlsx.AutoFilter{TopLeftCell: tpCell, BottomRightCell: brCell}
+}
+
+// addFooterRow adds the footer row with the totals of the columns (see RepColumns.Aggregate).
+// Data rows are the nrows rows following startRow, label is added to the first column if it has no total.
+func addFooterRow(sheet *xlsx.Sheet, cols []column, startRow, nrows int, label string) error {
+	_, err := addTotalRow(sheet, cols, startRow+1, startRow+nrows, 0, label)
+	return err
+}
+
+// addTotalRow adds a row with the totals of the columns for the rows firstRow to lastRow,
+// label is added to the column labelCol if it has no total.
+func addTotalRow(sheet *xlsx.Sheet, cols []column, firstRow, lastRow, labelCol int, label string) (*xlsx.Row, error) {
+	row := sheet.AddRow()
+
+	for c, col := range cols {
+		cell := row.AddCell()
+		if col.hasTotal() {
+			formula, err := col.totalFormula(RangeRef(c, firstRow, c, lastRow))
+			if err != nil {
+				return nil, err
+			}
+			cell.SetFloatWithFormat(0, col.totalFormat())
+			cell.SetFormula(formula)
+			totalStyle(cell)
+		} else {
+			footerStyle(cell)
+			if c == labelCol && label != "" {
+				cell.Value = label
+				s := cell.GetStyle()
+				s.Font.Bold = true
+				s.ApplyFont = true
+			}
+		}
+	}
+	return row, nil
+}
+
+// footerStyle sets the style of the cells of the footer and subtotal rows.
+func footerStyle(cell *xlsx.Cell) {
+	s := cell.GetStyle()
+	s.Fill.PatternType = "solid"
+	s.Fill.FgColor = footerFill
+	s.ApplyFill = true
+}
+
+// totalStyle sets the style of the footer and subtotal cells that hold a total.
+func totalStyle(cell *xlsx.Cell) {
+	footerStyle(cell)
+	s := cell.GetStyle()
+	s.Font.Bold = true
+	s.Font.Color = "00FF0000"
+	s.Alignment.Horizontal = "left"
+	s.ApplyAlignment = true
+	s.ApplyFont = true
+}
+
+// sheetRowKinds returns the kind of each row of a report sheet, as told by the styles set by the functions above.
+func sheetRowKinds(sheet *xlsx.Sheet) []rowKind {
+	kinds := make([]rowKind, len(sheet.Rows))
+	header := false
+	for r, row := range sheet.Rows {
+		switch fill := firstCellFill(row); {
+		case !header && fill == headerFill:
+			kinds[r] = headerRow
+			header = true
+		case !header:
+			kinds[r] = titleRow
+		case fill == footerFill && row.OutlineLevel > 0:
+			kinds[r] = subtotalRow
+		case fill == footerFill && kinds[r-1] == footerRow:
+			// Only the grand total row follows the footer
+			kinds[r] = grandTotalRow
+		case fill == footerFill:
+			kinds[r] = footerRow
+		default:
+			kinds[r] = dataRow
+		}
+	}
+	return kinds
+}
+
+// firstCellFill returns the fill color of the first cell of row, empty if it has none.
+func firstCellFill(row *xlsx.Row) string {
+	if row == nil || len(row.Cells) == 0 {
+		return ""
+	}
+	s := row.Cells[0].GetStyle()
+	if !s.ApplyFill {
+		return ""
+	}
+	return s.Fill.FgColor
+}
@@ -0,0 +1,142 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/tealeg/xlsx"
+)
+
+// sliceRows is implemented by ReportData types that keep their rows in a slice.
+type sliceRows interface {
+	rowSlice() interface{}
+}
+
+/*
+SliceReport is a ReportData implementation that keeps rows in the order returned by the query,
+so no artificial map key is needed to preserve it.
+
+T must be a struct with fields for each column (same as the map items used with ExcelReport).
+Scan is called by LoadRows for each row and must return the row as a T.
+
+When Scan is nil, query columns are mapped automatically to the fields of T by their db tag or name,
+ignoring case and underscores (i.e. a CUSTOMER_NUMBER column is loaded into a CustomerNumber field).
+Fields can be sql.Null* types or pointers to receive NULL values. A column with no matching field is an error.
+*/
+type SliceReport[T any] struct {
+	Rows []T
+	Scan func(rows *sql.Rows) (T, error)
+}
+
+// LoadRows implements ReportData, appending each row returned by Scan to Rows.
+func (r *SliceReport[T]) LoadRows(rows *sql.Rows) error {
+	if err := checkStruct[T](); err != nil {
+		return err
+	}
+	if r.Scan == nil {
+		return r.loadStructs(rows)
+	}
+
+	for rows.Next() {
+		v, err := r.Scan(rows)
+		if err != nil {
+			return err
+		}
+		r.Rows = append(r.Rows, v)
+	}
+	return rows.Err()
+}
+
+// loadStructs loads the rows mapping each column to a field of T.
+func (r *SliceReport[T]) loadStructs(rows *sql.Rows) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	scanner, err := newStructScanner(reflect.TypeOf((*T)(nil)).Elem(), cols)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var v T
+		if err := scanner.scan(rows, reflect.ValueOf(&v).Elem()); err != nil {
+			return err
+		}
+		r.Rows = append(r.Rows, v)
+	}
+	return rows.Err()
+}
+
+func (r *SliceReport[T]) rowSlice() interface{} {
+	return r.Rows
+}
+
+// ExcelFromSlice generates a single sheet report with one row for each item of rows, in the same order.
+// T must be a struct with fields for each column, RepCols should be defined as in ExcelReport.
+func ExcelFromSlice[T any](rp RepParams, rows []T) error {
+	return ExcelFromSliceContext(context.Background(), rp, rows)
+}
+
+// ExcelFromSliceContext is like ExcelFromSlice but stops generating the report when ctx is done.
+func ExcelFromSliceContext[T any](ctx context.Context, rp RepParams, rows []T) error {
+	if rp.FilePath == "" {
+		rp.FilePath = rp.RepTitle + outputExts[outputFor(rp.Output, "")]
+	}
+
+	file, err := buildFromSlice(ctx, rp, rows)
+	if file == nil {
+		return err
+	}
+
+	return errors.Join(err, saveOutput(file, rp.FilePath, rp, rp.logger()))
+}
+
+// WriteExcelFromSlice is like ExcelFromSliceContext but writes the workbook to w instead of a file.
+// rp.FilePath is ignored.
+func WriteExcelFromSlice[T any](ctx context.Context, w io.Writer, rp RepParams, rows []T) error {
+	file, err := buildFromSlice(ctx, rp, rows)
+	if file == nil {
+		return err
+	}
+
+	return errors.Join(err, writeOutput(w, file, outputFor(rp.Output, ""), rp))
+}
+
+// buildFromSlice generates the workbook for ExcelFromSlice and its variants.
+// Errors are handled as in buildMultiSheet.
+func buildFromSlice[T any](ctx context.Context, rp RepParams, rows []T) (*xlsx.File, error) {
+	var file *xlsx.File
+
+	file = xlsx.NewFile()
+	rp.RepSheet = sheetName(rp)
+
+	err := checkStruct[T]()
+	if err == nil {
+		err = genSheet(ctx, file, rp, rows)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		err = &SheetError{Sheet: rp.RepSheet, Err: err}
+		if rp.StrictMode {
+			return nil, err
+		}
+	}
+
+	return file, err
+}
+
+// checkStruct returns an error if T is not a struct.
+func checkStruct[T any]() error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("%v is not a struct", t)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+type sliceTestRow struct {
+	Name    CellStr
+	Balance CellCurrency
+}
+
+func TestWriteExcelFromSlice(t *testing.T) {
+	rp := RepParams{
+		RepTitle:   "Slice",
+		RepCols:    []RepColumns{{Title: "Name"}, {Title: "Balance", SumFlag: true}},
+		NoTitleRow: true}
+	rows := []sliceTestRow{{"zeta", 1}, {"alpha", 2}, {"mid", 3}}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromSlice(context.Background(), &buf, rp, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := xlsx.OpenBinary(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := file.Sheets[0]
+	if sheet.Name != "Slice" {
+		t.Errorf("sheet name = %q, want %q", sheet.Name, "Slice")
+	}
+	for i, r := range rows {
+		if got := sheet.Cell(i+1, 0).Value; got != string(r.Name) {
+			t.Errorf("row %d name = %q, want %q", i+1, got, r.Name)
+		}
+	}
+	if got := sheet.Cell(4, 1).Formula(); got != "=SUBTOTAL(109,B2:B4)" {
+		t.Errorf("footer formula = %q", got)
+	}
+}
+
+func TestWriteExcelFromSliceNotStruct(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteExcelFromSlice(context.Background(), &buf, RepParams{RepTitle: "Ints", StrictMode: true}, []int{1, 2})
+	if _, ok := err.(*SheetError); !ok {
+		t.Fatalf("err = %v, want *SheetError", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("workbook written in strict mode")
+	}
+}
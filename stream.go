@@ -0,0 +1,416 @@
+package xlsrpt
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+/*
+ExcelFromDBStream is like ExcelFromDBContext but the sheet is written to the file as the rows are read
+from the database, instead of building the whole workbook in memory first.
+Use it for very large result sets, memory usage doesn't depend on the number of rows.
+
+Title row, header styles, AltBg, GroupBy, footer totals and Overflow are supported as on ExcelFromDB,
+footer formulas are calculated by excel when the file is opened.
+Pivot reports are supported too, although their cells are accumulated in memory.
+
+If the report fails the file is removed, see WriteExcelFromDBStream for details.
+Only xlsx is streamed, an error is returned (and no file is created) when rp.Output or the extension
+of rp.FilePath choose another format (see RepParams.Output).
+*/
+func ExcelFromDBStream(ctx context.Context, rp RepParams, db *sql.DB) error {
+	if rp.FilePath == "" {
+		rp.FilePath = rp.RepTitle + ".xlsx"
+	}
+
+	if err := streamOutput(rp.Output, rp.FilePath); err != nil {
+		return err
+	}
+
+	return streamToFile(xlsxPath(rp.FilePath, rp.logger()), func(w io.Writer) (bool, error) {
+		return streamMultiSheetFromDB(ctx, w, []MultiSheetRep{{Params: rp, DB: db}})
+	})
+}
+
+// ExcelMultiSheetFromDBStream is like ExcelMultiSheetFromDBContext but sheets are streamed to the file
+// (see ExcelFromDBStream).
+func ExcelMultiSheetFromDBStream(ctx context.Context, filePath string, reports []MultiSheetRep) error {
+	if err := streamOutput(reportsParams(reports).Output, filePath); err != nil {
+		return err
+	}
+
+	return streamToFile(xlsxPath(filePath, reportsLogger(reports)), func(w io.Writer) (bool, error) {
+		return streamMultiSheetFromDB(ctx, w, reports)
+	})
+}
+
+/*
+WriteExcelFromDBStream is like WriteExcelFromDB but the workbook is written to w as the rows are read
+from the database (see ExcelFromDBStream). rp.FilePath is ignored.
+
+Since the workbook is written while it is generated, when ctx is done or a sheet with StrictMode fails
+the content written to w is not a valid workbook and must be discarded.
+Otherwise a sheet that fails is ended with the rows written so far and reported in the returned error.
+*/
+func WriteExcelFromDBStream(ctx context.Context, w io.Writer, rp RepParams, db *sql.DB) error {
+	_, err := streamMultiSheetFromDB(ctx, w, []MultiSheetRep{{Params: rp, DB: db}})
+	return err
+}
+
+// WriteExcelMultiSheetFromDBStream is like WriteExcelMultiSheetFromDB but sheets are streamed to w
+// (see WriteExcelFromDBStream).
+func WriteExcelMultiSheetFromDBStream(ctx context.Context, w io.Writer, reports []MultiSheetRep) error {
+	_, err := streamMultiSheetFromDB(ctx, w, reports)
+	return err
+}
+
+// streamOutput returns an error when out and filePath choose a format other than xlsx, see outputFor.
+func streamOutput(out Output, filePath string) error {
+	if outputFor(out, filePath) != OutputXLSX {
+		return fmt.Errorf("file %q: stream reports can only be written as xlsx", filePath)
+	}
+	return nil
+}
+
+// streamToFile creates the file path and writes it using write, the file is removed
+// if write doesn't complete the workbook.
+func streamToFile(path string, write func(w io.Writer) (bool, error)) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	completed, err := write(f)
+	if closeErr := f.Close(); closeErr != nil {
+		completed = false
+		err = errors.Join(err, closeErr)
+	}
+	if !completed {
+		os.Remove(path)
+	}
+	return err
+}
+
+// streamMultiSheetFromDB writes the workbook of reports to w, one sheet at a time.
+// Errors are handled as in buildMultiSheet, completed is false when the workbook was not completed.
+func streamMultiSheetFromDB(ctx context.Context, w io.Writer, reports []MultiSheetRep) (completed bool, err error) {
+	var errs []error
+
+	wb := newStreamWorkbook(w)
+
+	for _, k := range reports {
+		k.Params.RepSheet = sheetName(k.Params)
+		log := k.Params.logger()
+		log.Info("Adding Sheet", "sheet", k.Params.RepSheet)
+
+		start := time.Now()
+		err := wb.addSheet(ctx, k.Params, k.DB)
+		log.Debug("Sheet streamed", "sheet", k.Params.RepSheet, "duration", time.Since(start))
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		if wb.err != nil {
+			return false, wb.err
+		}
+		if err != nil {
+			err = &SheetError{Sheet: k.Params.RepSheet, Err: err}
+			if k.Params.StrictMode {
+				return false, err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if err := wb.close(); err != nil {
+		return false, errors.Join(append(errs, err)...)
+	}
+	return true, errors.Join(errs...)
+}
+
+// streamWorkbook writes a workbook to a zip archive, sheets are written as their rows are added.
+// Rows are generated on a scratch sheet with the same helpers used for regular workbooks and written
+// out on each flush, so cell values and styles are the same.
+// (xlsx.StreamFile is not used since it doesn't support formulas, custom number formats nor column widths)
+type streamWorkbook struct {
+	zip     *zip.Writer
+	scratch *xlsx.File // Validates sheet names, holds the rows not flushed yet
+	styles  *streamStyles
+	sheets  []string
+	filters []string // Autofilter range of each sheet (if any)
+	err     error    // Error writing the workbook
+
+	cur          *streamSheet // Sheet being written
+	outlineLevel int          // Outline level of the sheets of the current report
+}
+
+// newStreamWorkbook returns a streamWorkbook that writes to w.
+func newStreamWorkbook(w io.Writer) *streamWorkbook {
+	return &streamWorkbook{zip: zip.NewWriter(w), scratch: xlsx.NewFile(), styles: newStreamStyles()}
+}
+
+// addSheet adds the sheet (or sheets, see RepParams.Overflow) of rp.
+// The sheet is ended with the rows written so far if the report fails.
+// Errors writing the workbook are kept in wb.err, the workbook can't be completed after that.
+func (wb *streamWorkbook) addSheet(ctx context.Context, rp RepParams, db *sql.DB) error {
+	wb.outlineLevel = 0
+	if len(rp.GroupBy) > 0 && rp.Pivot == nil {
+		wb.outlineLevel = len(rp.GroupBy) + 1
+	}
+
+	err := fillSheetFromDB(ctx, wb, rp, db)
+	if wb.err == nil {
+		wb.err = wb.endSheet()
+	}
+	if wb.err != nil {
+		return wb.err
+	}
+	return err
+}
+
+// newSheet ends the current sheet and starts writing a new one, see sheetSink.
+func (wb *streamWorkbook) newSheet(name string) (*xlsx.Sheet, error) {
+	if wb.err = wb.endSheet(); wb.err != nil {
+		return nil, wb.err
+	}
+
+	sheet, err := wb.scratch.AddSheet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	part, err := wb.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(wb.sheets)+1))
+	if err != nil {
+		wb.err = err
+		return nil, err
+	}
+	wb.sheets = append(wb.sheets, name)
+
+	wb.cur = &streamSheet{Sheet: sheet, w: bufio.NewWriterSize(part, 64*1024), styles: wb.styles, outlineLevel: wb.outlineLevel}
+	return sheet, nil
+}
+
+// flush writes the rows added to the current sheet, see sheetSink.
+func (wb *streamWorkbook) flush() error {
+	if wb.err = wb.cur.flush(); wb.err != nil {
+		return wb.err
+	}
+	return nil
+}
+
+// endSheet ends the current sheet (if any).
+func (wb *streamWorkbook) endSheet() error {
+	if wb.cur == nil {
+		return nil
+	}
+	ss := wb.cur
+	wb.cur = nil
+	if err := ss.close(); err != nil {
+		return err
+	}
+	wb.filters = append(wb.filters, ss.filter)
+	return nil
+}
+
+// close writes the workbook parts and ends the zip archive.
+func (wb *streamWorkbook) close() error {
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	if err := wb.writePart("[Content_Types].xml", b.String()); err != nil {
+		return err
+	}
+
+	b.Reset()
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>`)
+	b.WriteString(`</Relationships>`)
+	if err := wb.writePart("_rels/.rels", b.String()); err != nil {
+		return err
+	}
+
+	b.Reset()
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	if err := wb.writePart("xl/_rels/workbook.xml.rels", b.String()); err != nil {
+		return err
+	}
+
+	b.Reset()
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range wb.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+	}
+	b.WriteString(`</sheets>`)
+	var names strings.Builder
+	for i, filter := range wb.filters {
+		if filter != "" {
+			ref := "'" + strings.ReplaceAll(wb.sheets[i], "'", "''") + "'!" + filter
+			fmt.Fprintf(&names, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s</definedName>`, i, xmlEscape(ref))
+		}
+	}
+	if names.Len() > 0 {
+		b.WriteString(`<definedNames>` + names.String() + `</definedNames>`)
+	}
+	// Footer formulas have no cached value, excel must calculate them
+	b.WriteString(`<calcPr fullCalcOnLoad="1"/></workbook>`)
+	if err := wb.writePart("xl/workbook.xml", b.String()); err != nil {
+		return err
+	}
+
+	if err := wb.writePart("xl/styles.xml", wb.styles.xmlPart()); err != nil {
+		return err
+	}
+	return wb.zip.Close()
+}
+
+// writePart adds a part with content to the zip archive.
+func (wb *streamWorkbook) writePart(name, content string) error {
+	return writeZipPart(wb.zip, name, content)
+}
+
+// streamSheet writes the XML of a sheet as its rows are added to the embedded scratch sheet.
+type streamSheet struct {
+	*xlsx.Sheet
+	w            *bufio.Writer
+	styles       *streamStyles
+	outlineLevel int
+	started      bool
+	rowNum       int    // Number of the last row written
+	filter       string // Autofilter range, set by close
+}
+
+// flush writes the rows added to the sheet since the last call and removes them from the sheet.
+// Rows are buffered, they reach the archive as the buffer fills up and when the sheet is closed.
+func (ss *streamSheet) flush() error {
+	if !ss.started {
+		ss.started = true
+		ss.writeStart()
+	}
+
+	for _, row := range ss.Rows {
+		ss.rowNum++
+		ss.writeRow(row)
+	}
+	ss.Rows = nil
+	// Returns the error of the last write to the archive (if any), nothing is written
+	_, err := ss.w.Write(nil)
+	return err
+}
+
+// close writes the remaining rows and the end of the sheet.
+func (ss *streamSheet) close() error {
+	if err := ss.flush(); err != nil {
+		return err
+	}
+
+	ss.w.WriteString(`</sheetData>`)
+	if af := ss.AutoFilter; af != nil {
+		ss.filter = absRef(af.TopLeftCell) + ":" + absRef(af.BottomRightCell)
+		fmt.Fprintf(ss.w, `<autoFilter ref="%s:%s"/>`, af.TopLeftCell, af.BottomRightCell)
+	}
+	ss.w.WriteString(`</worksheet>`)
+	return ss.w.Flush()
+}
+
+// writeStart writes the sheet properties and columns, up to the start of the sheet data.
+func (ss *streamSheet) writeStart() {
+	ss.w.WriteString(xml.Header)
+	ss.w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	if ss.outlineLevel > 0 {
+		fmt.Fprintf(ss.w, `<sheetFormatPr defaultRowHeight="15" outlineLevelRow="%d"/>`, ss.outlineLevel)
+	}
+	if len(ss.Cols) > 0 {
+		ss.w.WriteString(`<cols>`)
+		for c, col := range ss.Cols {
+			width := col.Width
+			if width == 0 {
+				width = xlsx.ColWidth
+			}
+			fmt.Fprintf(ss.w, `<col min="%d" max="%d" width="%s" customWidth="1"`, c+1, c+1, strconv.FormatFloat(width, 'f', -1, 64))
+			if col.Hidden {
+				ss.w.WriteString(` hidden="1"`)
+			}
+			ss.w.WriteString(`/>`)
+		}
+		ss.w.WriteString(`</cols>`)
+	}
+	ss.w.WriteString(`<sheetData>`)
+}
+
+// writeRow writes row as row number ss.rowNum.
+func (ss *streamSheet) writeRow(row *xlsx.Row) {
+	fmt.Fprintf(ss.w, `<row r="%d"`, ss.rowNum)
+	if row.OutlineLevel > 0 {
+		fmt.Fprintf(ss.w, ` outlineLevel="%d"`, row.OutlineLevel)
+	}
+	ss.w.WriteString(`>`)
+
+	for c, cell := range row.Cells {
+		fmt.Fprintf(ss.w, `<c r="%s"`, CellRef(c, ss.rowNum))
+		if s := ss.styles.index(cell); s != 0 {
+			fmt.Fprintf(ss.w, ` s="%d"`, s)
+		}
+
+		formula := strings.TrimPrefix(cell.Formula(), "=")
+		switch {
+		case formula != "":
+			if cell.Type() == xlsx.CellTypeStringFormula {
+				ss.w.WriteString(` t="str"`)
+			}
+			fmt.Fprintf(ss.w, `><f>%s</f></c>`, xmlEscape(formula))
+		case cell.Value == "":
+			ss.w.WriteString(`/>`)
+		case cell.Type() == xlsx.CellTypeNumeric || cell.Type() == xlsx.CellTypeDate:
+			fmt.Fprintf(ss.w, `><v>%s</v></c>`, cell.Value)
+		case cell.Type() == xlsx.CellTypeBool:
+			fmt.Fprintf(ss.w, ` t="b"><v>%s</v></c>`, cell.Value)
+		default:
+			fmt.Fprintf(ss.w, ` t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(cell.Value))
+		}
+	}
+	ss.w.WriteString(`</row>`)
+}
+
+// absRef returns the absolute reference of cell (i.e. "$A$4" for "A4").
+func absRef(cell string) string {
+	i := strings.IndexAny(cell, "0123456789")
+	if i < 0 {
+		return cell
+	}
+	return "$" + cell[:i] + "$" + cell[i:]
+}
+
+// xmlEscape returns s escaped for XML text and attribute values.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,225 @@
+package xlsrpt
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+func streamTestDB() fakeResult {
+	created := time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC)
+	return fakeResult{
+		cols:  []string{"Region", "Name", "Amount", "Created"},
+		types: []string{"VARCHAR", "VARCHAR", "DECIMAL", "DATETIME"},
+		sizes: map[int][2]int64{2: {10, 2}},
+		rows: [][]driver.Value{
+			{"North", "Tom & Jerry <Inc>", "10.5", created},
+			{"North", "Ann", "2", created},
+			{"South", nil, "7.25", created}}}
+}
+
+func TestWriteExcelFromDBStream(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"stream": streamTestDB()})
+	defer db.Close()
+
+	rp := RepParams{
+		RepTitle:    "Stream Report",
+		RepSheet:    "Stream",
+		Query:       "stream",
+		AltBg:       true,
+		AutoFilter:  true,
+		GroupBy:     []string{"Region"},
+		FooterLabel: "Total",
+		RepCols:     []RepColumns{{Column: "Amount", SumFlag: true}},
+		Logger:      discardLogger{}}
+
+	var buf bytes.Buffer
+	if err := WriteExcelFromDBStream(context.Background(), &buf, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := xlsx.OpenBinary(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The streamed sheet must match the sheet generated in memory
+	file := xlsx.NewFile()
+	if err := genSheetFromDB(context.Background(), file, rp, db); err != nil {
+		t.Fatal(err)
+	}
+	want, got := file.Sheets[0], streamed.Sheet["Stream"]
+	if got == nil {
+		t.Fatalf("sheet Stream not found")
+	}
+	if len(got.Rows) != len(want.Rows) {
+		t.Fatalf("streamed sheet has %d rows, want %d", len(got.Rows), len(want.Rows))
+	}
+	for r, row := range want.Rows {
+		if got.Rows[r].OutlineLevel != row.OutlineLevel {
+			t.Errorf("row %d: outline level = %d, want %d", r+1, got.Rows[r].OutlineLevel, row.OutlineLevel)
+		}
+		for c, cell := range row.Cells {
+			gc := got.Cell(r, c)
+			ref := CellRef(c, r+1)
+			if f := strings.TrimPrefix(cell.Formula(), "="); f != "" {
+				if gc.Formula() != f {
+					t.Errorf("%s: formula = %q, want %q", ref, gc.Formula(), f)
+				}
+				continue
+			}
+			if gc.Value != cell.Value || numFmt(gc) != numFmt(cell) {
+				t.Errorf("%s: value %q (%q), want %q (%q)", ref, gc.Value, gc.NumFmt, cell.Value, cell.NumFmt)
+			}
+			ws, gs := cell.GetStyle(), gc.GetStyle()
+			if ws.ApplyFill && gs.Fill.FgColor != ws.Fill.FgColor {
+				t.Errorf("%s: fill = %q, want %q", ref, gs.Fill.FgColor, ws.Fill.FgColor)
+			}
+			if ws.ApplyFont && gs.Font.Bold != ws.Font.Bold {
+				t.Errorf("%s: bold = %v, want %v", ref, gs.Font.Bold, ws.Font.Bold)
+			}
+		}
+	}
+	if got.Col(0).Width != 28 {
+		t.Errorf("column width = %v, want 28", got.Col(0).Width)
+	}
+
+	// xlsx doesn't read the autofilter
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []struct{ name, want string }{
+		{"xl/worksheets/sheet1.xml", `<autoFilter ref="A4:D9"/>`},
+		{"xl/workbook.xml", `localSheetId="0" hidden="1">&#39;Stream&#39;!$A$4:$D$9</definedName>`},
+	} {
+		f, err := zr.Open(part.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(f)
+		f.Close()
+		if !strings.Contains(string(content), part.want) {
+			t.Errorf("%s doesn't contain %s", part.name, part.want)
+		}
+	}
+}
+
+// countWriter counts the calls to Write.
+type countWriter struct{ writes int }
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	return len(p), nil
+}
+
+func TestStreamSheetBuffer(t *testing.T) {
+	sheet, err := xlsx.NewFile().AddSheet("Buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cw countWriter
+	ss := &streamSheet{Sheet: sheet, w: bufio.NewWriterSize(&cw, 64*1024), styles: newStreamStyles()}
+
+	for i := 0; i < 100; i++ {
+		sheet.AddRow().AddCell().SetInt(i)
+		if err := ss.flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Rows stay in the buffer until it is full or the sheet is closed
+	if cw.writes != 0 {
+		t.Errorf("%d writes before the sheet is closed", cw.writes)
+	}
+	if err := ss.close(); err != nil {
+		t.Fatal(err)
+	}
+	if cw.writes != 1 {
+		t.Errorf("%d writes, want 1", cw.writes)
+	}
+}
+
+// numFmt returns the number format of cell, empty for General.
+func numFmt(cell *xlsx.Cell) string {
+	if cell.NumFmt == "general" {
+		return ""
+	}
+	return cell.NumFmt
+}
+
+func TestExcelFromDBStreamCancel(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"stream": streamTestDB()})
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "cancel.xlsx")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rp := RepParams{RepTitle: "Cancel", Query: "stream", FilePath: path, Logger: discardLogger{}}
+	if err := ExcelFromDBStream(ctx, rp, db); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file of canceled report was not removed")
+	}
+}
+
+func TestExcelMultiSheetFromDBStream(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"stream": streamTestDB()})
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "multi.xlsx")
+	reports := []MultiSheetRep{
+		{Params: RepParams{RepSheet: "First", Query: "stream", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Failed", Query: "missing", Logger: discardLogger{}}, DB: db},
+		{Params: RepParams{RepSheet: "Pivot", Query: "stream", Pivot: &Pivot{Rows: "Region", Columns: "Created", Values: "Amount"}, Logger: discardLogger{}}, DB: db},
+	}
+	err := ExcelMultiSheetFromDBStream(context.Background(), path, reports)
+	var sheetErr *SheetError
+	if !errors.As(err, &sheetErr) || sheetErr.Sheet != "Failed" {
+		t.Fatalf("err = %v, want error of sheet Failed", err)
+	}
+
+	file, err := xlsx.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Sheets) != 3 {
+		t.Fatalf("workbook has %d sheets, want 3", len(file.Sheets))
+	}
+	if got := file.Sheet["Pivot"].Cell(6, 2).Value; got != "19.75" {
+		t.Errorf("pivot grand total = %q, want %q", got, "19.75")
+	}
+}
+
+func TestExcelFromDBStreamOutput(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"stream": streamTestDB()})
+	defer db.Close()
+
+	dir := t.TempDir()
+	rp := RepParams{RepTitle: "Output", Query: "stream", FilePath: filepath.Join(dir, "report.ods"), Logger: discardLogger{}}
+	if err := ExcelFromDBStream(context.Background(), rp, db); err == nil {
+		t.Error("ExcelFromDBStream to report.ods returned no error")
+	}
+	rp.FilePath, rp.Output = filepath.Join(dir, "report.xlsx"), OutputCSV
+	if err := ExcelFromDBStream(context.Background(), rp, db); err == nil {
+		t.Error("ExcelFromDBStream with OutputCSV returned no error")
+	}
+	reports := []MultiSheetRep{{Params: RepParams{RepSheet: "First", Query: "stream", Logger: discardLogger{}}, DB: db}}
+	if err := ExcelMultiSheetFromDBStream(context.Background(), filepath.Join(dir, "multi.csv"), reports); err == nil {
+		t.Error("ExcelMultiSheetFromDBStream to multi.csv returned no error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d files were written, want none", len(entries))
+	}
+}
@@ -0,0 +1,172 @@
+package xlsrpt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// streamStyles collects the cell styles of a streamed workbook, see streamWorkbook.
+// Style 0 is the default style (no number format, default font, no fill).
+type streamStyles struct {
+	fonts   []streamFont
+	fills   []string // Fill color, first two are the fills required by excel
+	numFmts []string // Custom number formats, with ids starting at 164
+	xfs     []streamStyle
+	ids     map[streamStyle]int
+}
+
+// streamFont is a font of a streamed workbook.
+type streamFont struct {
+	name  string
+	size  int
+	bold  bool
+	color string
+}
+
+// streamStyle is a cell style of a streamed workbook, fields are indexes of streamStyles items.
+type streamStyle struct {
+	numFmt int // 0 is General
+	font   int
+	fill   int
+	halign string
+}
+
+// newStreamStyles returns a streamStyles with the default style.
+func newStreamStyles() *streamStyles {
+	def := xlsx.DefaultFont()
+	return &streamStyles{
+		fonts: []streamFont{{name: def.Name, size: def.Size}},
+		fills: []string{"none", "gray125"},
+		xfs:   []streamStyle{{}},
+		ids:   map[streamStyle]int{{}: 0},
+	}
+}
+
+// index returns the style index of cell, the style is added if it is new.
+func (st *streamStyles) index(cell *xlsx.Cell) int {
+	var xf streamStyle
+
+	s := cell.GetStyle()
+	if s.ApplyFont {
+		xf.font = st.font(streamFont{name: s.Font.Name, size: s.Font.Size, bold: s.Font.Bold, color: s.Font.Color})
+	}
+	if s.ApplyFill && s.Fill.PatternType == "solid" {
+		xf.fill = st.fill(s.Fill.FgColor)
+	}
+	if s.ApplyAlignment {
+		xf.halign = s.Alignment.Horizontal
+	}
+	if format := cell.GetNumberFormat(); format != "" && format != "general" {
+		xf.numFmt = st.numFmt(format)
+	}
+
+	i, ok := st.ids[xf]
+	if !ok {
+		i = len(st.xfs)
+		st.xfs = append(st.xfs, xf)
+		st.ids[xf] = i
+	}
+	return i
+}
+
+// font returns the index of f, adding it if needed.
+func (st *streamStyles) font(f streamFont) int {
+	for i, font := range st.fonts {
+		if font == f {
+			return i
+		}
+	}
+	st.fonts = append(st.fonts, f)
+	return len(st.fonts) - 1
+}
+
+// fill returns the index of the solid fill of color, adding it if needed.
+func (st *streamStyles) fill(color string) int {
+	for i, fill := range st.fills {
+		if fill == color {
+			return i
+		}
+	}
+	st.fills = append(st.fills, color)
+	return len(st.fills) - 1
+}
+
+// numFmt returns the id of the custom number format, adding it if needed.
+func (st *streamStyles) numFmt(format string) int {
+	for i, f := range st.numFmts {
+		if f == format {
+			return 164 + i
+		}
+	}
+	st.numFmts = append(st.numFmts, format)
+	return 164 + len(st.numFmts) - 1
+}
+
+// xmlPart returns the styles part of the workbook.
+func (st *streamStyles) xmlPart() string {
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(st.numFmts) > 0 {
+		fmt.Fprintf(&b, `<numFmts count="%d">`, len(st.numFmts))
+		for i, f := range st.numFmts {
+			fmt.Fprintf(&b, `<numFmt numFmtId="%d" formatCode="%s"/>`, 164+i, xmlEscape(f))
+		}
+		b.WriteString(`</numFmts>`)
+	}
+
+	fmt.Fprintf(&b, `<fonts count="%d">`, len(st.fonts))
+	for _, f := range st.fonts {
+		b.WriteString(`<font>`)
+		if f.bold {
+			b.WriteString(`<b/>`)
+		}
+		fmt.Fprintf(&b, `<sz val="%d"/>`, f.size)
+		if f.color != "" {
+			fmt.Fprintf(&b, `<color rgb="%s"/>`, xmlEscape(f.color))
+		}
+		fmt.Fprintf(&b, `<name val="%s"/></font>`, xmlEscape(f.name))
+	}
+	b.WriteString(`</fonts>`)
+
+	fmt.Fprintf(&b, `<fills count="%d">`, len(st.fills))
+	for i, color := range st.fills {
+		if i < 2 {
+			fmt.Fprintf(&b, `<fill><patternFill patternType="%s"/></fill>`, color)
+			continue
+		}
+		fmt.Fprintf(&b, `<fill><patternFill patternType="solid"><fgColor rgb="%s"/></patternFill></fill>`, xmlEscape(color))
+	}
+	b.WriteString(`</fills>`)
+
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+
+	fmt.Fprintf(&b, `<cellXfs count="%d">`, len(st.xfs))
+	for _, xf := range st.xfs {
+		fmt.Fprintf(&b, `<xf numFmtId="%d" fontId="%d" fillId="%d" borderId="0" xfId="0"`, xf.numFmt, xf.font, xf.fill)
+		if xf.numFmt != 0 {
+			b.WriteString(` applyNumberFormat="1"`)
+		}
+		if xf.font != 0 {
+			b.WriteString(` applyFont="1"`)
+		}
+		if xf.fill != 0 {
+			b.WriteString(` applyFill="1"`)
+		}
+		if xf.halign != "" {
+			fmt.Fprintf(&b, ` applyAlignment="1"><alignment horizontal="%s"/></xf>`, xmlEscape(xf.halign))
+			continue
+		}
+		b.WriteString(`/>`)
+	}
+	b.WriteString(`</cellXfs>`)
+
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package xlsrpt
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// tagName is the struct tag key used to define report columns, see RepColumns.
+const tagName = "xlsrpt"
+
+// column is the definition of a report column bound to a struct field.
+type column struct {
+	RepColumns
+	field int    // Index of the struct field
+	name  string // Name of the struct field
+}
+
+// structColumns returns the report columns for the struct type t.
+// Columns are defined by the struct tags, repCols (if any) overrides them by position.
+func structColumns(t reflect.Type, repCols []RepColumns, log Logger) ([]column, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%v is not a struct", t)
+	}
+
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		rc, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		if rc.Title == "" {
+			rc.Title = f.Name
+		}
+		cols = append(cols, column{RepColumns: rc, field: i, name: f.Name})
+	}
+
+	if len(repCols) > 0 && len(repCols) != len(cols) {
+		log.Warn("RepCols doesn't match the struct fields", "type", t, "repCols", len(repCols), "fields", len(cols))
+	}
+	for i := 0; i < len(repCols) && i < len(cols); i++ {
+		cols[i].RepColumns = overrideColumn(cols[i].RepColumns, repCols[i])
+	}
+
+	return cols, nil
+}
+
+// overrideColumn returns col with the values set on override.
+func overrideColumn(col, override RepColumns) RepColumns {
+	if override.Title != "" {
+		col.Title = override.Title
+	}
+	if override.Format != "" {
+		col.Format = override.Format
+	}
+	if override.Width != 0 {
+		col.Width = override.Width
+	}
+	if override.Aggregate != "" {
+		col.Aggregate = override.Aggregate
+	}
+	if override.Formula != "" {
+		col.Formula = override.Formula
+	}
+	if override.TotalFormat != "" {
+		col.TotalFormat = override.TotalFormat
+	}
+	col.SumFlag = col.SumFlag || override.SumFlag
+	col.Hidden = col.Hidden || override.Hidden
+	return col
+}
+
+// parseTag returns the column definition of an xlsrpt struct tag.
+func parseTag(tag string) (col RepColumns, err error) {
+	if tag == "" {
+		return col, nil
+	}
+
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch strings.TrimSpace(key) {
+		case "title":
+			col.Title = value
+		case "format":
+			col.Format = strings.TrimSpace(value)
+		case "sum":
+			col.SumFlag = true
+		case "agg":
+			col.Aggregate = strings.TrimSpace(value)
+		case "totalformat":
+			col.TotalFormat = strings.TrimSpace(value)
+		case "width":
+			col.Width, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return col, fmt.Errorf("invalid width %q", value)
+			}
+		case "hidden":
+			col.Hidden = true
+		default:
+			return col, fmt.Errorf("unknown %s tag option %q", tagName, opt)
+		}
+	}
+	return col, nil
+}
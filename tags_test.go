@@ -0,0 +1,64 @@
+package xlsrpt
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tagTestRow struct {
+	Name     CellStr `xlsrpt:"title=Customer Name,width=40"`
+	Number   int
+	Balance  float64 `xlsrpt:"title=Customer Balance,format=currency,sum"`
+	Internal string  `xlsrpt:"-"`
+	Notes    string  `xlsrpt:"hidden"`
+}
+
+func TestStructColumns(t *testing.T) {
+	cols, err := structColumns(reflect.TypeOf(tagTestRow{}), nil, discardLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []column{
+		{RepColumns{Title: "Customer Name", Width: 40}, 0, "Name"},
+		{RepColumns{Title: "Number"}, 1, "Number"},
+		{RepColumns{Title: "Customer Balance", Format: FormatCurrency, SumFlag: true}, 2, "Balance"},
+		{RepColumns{Title: "Notes", Hidden: true}, 4, "Notes"},
+	}
+	if !reflect.DeepEqual(cols, want) {
+		t.Errorf("structColumns() = %+v, want %+v", cols, want)
+	}
+}
+
+func TestStructColumnsOverride(t *testing.T) {
+	repCols := []RepColumns{{Title: "Name"}, {Title: "Account", Format: FormatText}}
+	cols, err := structColumns(reflect.TypeOf(tagTestRow{}), repCols, discardLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cols[0].Title != "Name" || cols[0].Width != 40 {
+		t.Errorf("column 0 = %+v", cols[0])
+	}
+	if cols[1].Title != "Account" || cols[1].Format != FormatText {
+		t.Errorf("column 1 = %+v", cols[1])
+	}
+	if cols[2].Title != "Customer Balance" || !cols[2].SumFlag {
+		t.Errorf("column 2 = %+v", cols[2])
+	}
+}
+
+func TestParseTagErrors(t *testing.T) {
+	for _, tag := range []string{"width=wide", "total", "title=A,bold"} {
+		if _, err := parseTag(tag); err == nil {
+			t.Errorf("parseTag(%q) returned no error", tag)
+		}
+	}
+}
+
+// discardLogger is a Logger that ignores all messages.
+type discardLogger struct{}
+
+func (discardLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (discardLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (discardLogger) Warn(msg string, keysAndValues ...interface{})  {}
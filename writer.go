@@ -0,0 +1,96 @@
+package xlsrpt
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+)
+
+// WriteExcelReport is like ExcelReportContext but writes the workbook to w instead of a file.
+// rp.FilePath is ignored.
+//
+// As when saving to a file, sheet errors are returned after the workbook is written
+// unless StrictMode is set (see RepParams).
+func WriteExcelReport(ctx context.Context, w io.Writer, rp RepParams, rptData ReportData, db *sql.DB) error {
+	file, err := buildReport(ctx, rp, rptData, db)
+	if file == nil {
+		return err
+	}
+
+	return errors.Join(err, writeOutput(w, file, outputFor(rp.Output, ""), rp))
+}
+
+// WriteExcelMultiSheet is like ExcelMultiSheetContext but writes the workbook to w instead of a file.
+func WriteExcelMultiSheet(ctx context.Context, w io.Writer, reports []MultiSheetRep) error {
+	file, err := buildMultiSheet(ctx, reports)
+	if file == nil {
+		return err
+	}
+
+	p := reportsParams(reports)
+	return errors.Join(err, writeOutput(w, file, outputFor(p.Output, ""), p))
+}
+
+// WriteExcelFromDB is like ExcelFromDBContext but writes the workbook to w instead of a file.
+// rp.FilePath is ignored.
+func WriteExcelFromDB(ctx context.Context, w io.Writer, rp RepParams, db *sql.DB) error {
+	file, err := buildFromDB(ctx, rp, db)
+	if file == nil {
+		return err
+	}
+
+	return errors.Join(err, writeOutput(w, file, outputFor(rp.Output, ""), rp))
+}
+
+// WriteExcelMultiSheetFromDB is like ExcelMultiSheetFromDBContext but writes the workbook to w instead of a file.
+func WriteExcelMultiSheetFromDB(ctx context.Context, w io.Writer, reports []MultiSheetRep) error {
+	file, err := buildMultiSheetFromDB(ctx, reports)
+	if file == nil {
+		return err
+	}
+
+	p := reportsParams(reports)
+	return errors.Join(err, writeOutput(w, file, outputFor(p.Output, ""), p))
+}
+
+// ExcelReportBytes returns the workbook generated by WriteExcelReport.
+func ExcelReportBytes(ctx context.Context, rp RepParams, rptData ReportData, db *sql.DB) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteExcelReport(ctx, &buf, rp, rptData, db)
+	if buf.Len() == 0 {
+		return nil, err
+	}
+	return buf.Bytes(), err
+}
+
+// ExcelMultiSheetBytes returns the workbook generated by WriteExcelMultiSheet.
+func ExcelMultiSheetBytes(ctx context.Context, reports []MultiSheetRep) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteExcelMultiSheet(ctx, &buf, reports)
+	if buf.Len() == 0 {
+		return nil, err
+	}
+	return buf.Bytes(), err
+}
+
+// ExcelFromDBBytes returns the workbook generated by WriteExcelFromDB.
+func ExcelFromDBBytes(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteExcelFromDB(ctx, &buf, rp, db)
+	if buf.Len() == 0 {
+		return nil, err
+	}
+	return buf.Bytes(), err
+}
+
+// ExcelMultiSheetFromDBBytes returns the workbook generated by WriteExcelMultiSheetFromDB.
+func ExcelMultiSheetFromDBBytes(ctx context.Context, reports []MultiSheetRep) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteExcelMultiSheetFromDB(ctx, &buf, reports)
+	if buf.Len() == 0 {
+		return nil, err
+	}
+	return buf.Bytes(), err
+}
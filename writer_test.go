@@ -0,0 +1,66 @@
+package xlsrpt
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+// bytesFuncs runs a report of rp with each of the entry points that return the workbook bytes.
+var bytesFuncs = map[string]func(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error){
+	"ExcelReportBytes": func(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error) {
+		return ExcelReportBytes(ctx, rp, &SliceReport[nameTestRow]{}, db)
+	},
+	"ExcelMultiSheetBytes": func(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error) {
+		return ExcelMultiSheetBytes(ctx, []MultiSheetRep{{Params: rp, Data: &SliceReport[nameTestRow]{}, DB: db}})
+	},
+	"ExcelFromDBBytes": func(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error) {
+		return ExcelFromDBBytes(ctx, rp, db)
+	},
+	"ExcelMultiSheetFromDBBytes": func(ctx context.Context, rp RepParams, db *sql.DB) ([]byte, error) {
+		return ExcelMultiSheetFromDBBytes(ctx, []MultiSheetRep{{Params: rp, DB: db}})
+	},
+}
+
+func TestReportBytes(t *testing.T) {
+	db := fakeDB(map[string]fakeResult{"names": nameTestDB(3)})
+	defer db.Close()
+
+	// FilePath is ignored, its extension doesn't choose the output and no file is written
+	path := filepath.Join(t.TempDir(), "names.csv")
+	rp := RepParams{RepSheet: "Names", NoTitleRow: true, Query: "names", FilePath: path, Logger: discardLogger{}}
+	for name, report := range bytesFuncs {
+		b, err := report(context.Background(), rp, db)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		file, err := xlsx.OpenBinary(b)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sheet := file.Sheet["Names"]
+		if sheet == nil || sheet.Cell(1, 0).Value != "Name A" || sheet.Cell(3, 0).Value != "Name C" {
+			t.Errorf("%s: rows of sheet Names not found", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: file of FilePath was written", name)
+		}
+	}
+}
+
+func TestReportBytesStrictMode(t *testing.T) {
+	db := fakeDB(nil)
+	defer db.Close()
+
+	rp := RepParams{RepSheet: "Failed", Query: "missing", StrictMode: true, Logger: discardLogger{}}
+	for name, report := range bytesFuncs {
+		b, err := report(context.Background(), rp, db)
+		if b != nil || err == nil {
+			t.Errorf("%s: %d bytes, err = %v, want nil bytes and StrictMode error", name, len(b), err)
+		}
+	}
+}